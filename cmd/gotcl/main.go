@@ -10,6 +10,7 @@ import (
 	"os"
 	"strings"
 
+	"github.com/midbel/gotcl/env"
 	"github.com/midbel/gotcl/interp"
 	"github.com/midbel/gotcl/stdlib"
 )
@@ -17,9 +18,13 @@ import (
 func main() {
 	var (
 		config = flag.String("i", "", "init file")
+		octal  = flag.Bool("o", false, "read integers with leading zero as octal")
 	)
 	flag.Parse()
 
+	// must be set before the interpreter is created, see env.LegacyOctal
+	env.LegacyOctal = *octal
+
 	i := interp.Interpret()
 	if *config != "" {
 		_, err := executeFile(i, *config)
@@ -34,8 +39,14 @@ func main() {
 	} else {
 		err = runFile(i, flag.Arg(0))
 	}
+	i.FlushAll()
 	if err != nil {
-		fmt.Fprintln(os.Stderr, err)
+		var e stdlib.Error
+		if errors.As(err, &e) {
+			fmt.Fprintln(os.Stderr, e.Info())
+		} else {
+			fmt.Fprintln(os.Stderr, err)
+		}
 		os.Exit(1)
 	}
 }
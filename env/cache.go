@@ -0,0 +1,51 @@
+package env
+
+type cache struct {
+	list   Value
+	dict   Value
+	number Value
+	err    error
+
+	str  string
+	done bool
+
+	reps map[string]any
+}
+
+func (c *cache) get(kind string) (any, bool) {
+	if c == nil || c.reps == nil {
+		return nil, false
+	}
+	r, ok := c.reps[kind]
+	return r, ok
+}
+
+func (c *cache) set(kind string, rep any) {
+	if c == nil {
+		return
+	}
+	if c.reps == nil {
+		c.reps = make(map[string]any)
+	}
+	c.reps[kind] = rep
+}
+
+func Internal(v Value, kind string, make func(string) (any, error)) (any, error) {
+	var c *cache
+	switch v := v.(type) {
+	case String:
+		c = v.cache
+	case List:
+		c = v.cache
+	default:
+	}
+	if r, ok := c.get(kind); ok {
+		return r, nil
+	}
+	r, err := make(v.String())
+	if err != nil {
+		return nil, err
+	}
+	c.set(kind, r)
+	return r, nil
+}
@@ -2,6 +2,7 @@ package env
 
 import (
 	"fmt"
+	"math"
 	"strconv"
 	"strings"
 
@@ -46,6 +47,9 @@ func ToInt(v Value) (int, error) {
 	if !ok {
 		return 0, nil
 	}
+	if math.IsInf(x.value, 0) || math.IsNaN(x.value) {
+		return 0, fmt.Errorf("expected integer but got %q", v.String())
+	}
 	return int(x.value), nil
 }
 
@@ -74,37 +78,132 @@ func ToBool(v Value) bool {
 }
 
 func split(str string) (Value, error) {
-	str = strings.TrimSpace(str)
-	scan, err := word.Scan(strings.NewReader(str))
-	if err != nil {
-		return nil, err
-	}
 	var list []Value
-	for {
-		w := scan.Scan()
-		if w.Type == word.EOF {
+	for i := 0; ; {
+		for i < len(str) && isListSpace(str[i]) {
+			i++
+		}
+		if i >= len(str) {
 			break
 		}
-		if w.Type == word.Blank {
-			continue
+		var (
+			elem string
+			j    int
+		)
+		switch str[i] {
+		case '{':
+			level := 1
+			for j = i + 1; j < len(str) && level > 0; j++ {
+				switch str[j] {
+				case '\\':
+					j++
+				case '{':
+					level++
+				case '}':
+					level--
+				}
+			}
+			if level > 0 {
+				return nil, fmt.Errorf("unmatched open brace in list")
+			}
+			elem = str[i+1 : j-1]
+		case '"':
+			for j = i + 1; j < len(str) && str[j] != '"'; j++ {
+				if str[j] == '\\' {
+					j++
+				}
+			}
+			if j >= len(str) {
+				return nil, fmt.Errorf("unmatched open quote in list")
+			}
+			elem = word.Unescape(str[i+1 : j])
+			j++
+		default:
+			for j = i; j < len(str) && !isListSpace(str[j]); j++ {
+				if str[j] == '\\' {
+					j++
+				}
+			}
+			if j > len(str) {
+				j = len(str)
+			}
+			elem = word.Unescape(str[i:j])
+		}
+		if j < len(str) && !isListSpace(str[j]) {
+			return nil, fmt.Errorf("list element followed by %q instead of space", str[j:j+1])
 		}
-		switch w.Type {
-		case word.Literal:
-		case word.Block:
-			w.Literal = fmt.Sprintf("{%s}", w.Literal)
-		case word.Variable:
-			w.Literal = fmt.Sprintf("$%s", w.Literal)
-		case word.Script:
-			w.Literal = fmt.Sprintf("[%s]", w.Literal)
-		case word.Quote:
-			w.Literal = fmt.Sprintf("\"%s\"", w.Literal)
+		list = append(list, Str(elem))
+		i = j
+	}
+	return ListFrom(list...), nil
+}
+
+func isListSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\v' || c == '\f'
+}
+
+func quote(str string) string {
+	if str == "" {
+		return "{}"
+	}
+	if !strings.ContainsAny(str, " \t\n\r\f\v{}[]$\";\\") {
+		return str
+	}
+	if canBrace(str) {
+		return "{" + str + "}"
+	}
+	var buf strings.Builder
+	for _, r := range str {
+		switch r {
+		case '\n':
+			buf.WriteString("\\n")
+		case '\t':
+			buf.WriteString("\\t")
+		case '\r':
+			buf.WriteString("\\r")
+		case '\f':
+			buf.WriteString("\\f")
+		case '\v':
+			buf.WriteString("\\v")
+		case ' ', '{', '}', '[', ']', '$', '"', ';', '\\':
+			buf.WriteRune('\\')
+			buf.WriteRune(r)
 		default:
-			return nil, fmt.Errorf("%s: unsupported token type", w)
+			buf.WriteRune(r)
 		}
-		list = append(list, Str(w.Literal))
 	}
-	if len(list) == 1 {
-		return list[0], nil
+	return buf.String()
+}
+
+// joinList protects a leading # so that the list is not read as a comment
+// when evaluated.
+func joinList(list []string) string {
+	if len(list) > 0 && strings.HasPrefix(list[0], "#") {
+		list[0] = "\\" + list[0]
 	}
-	return ListFrom(list...), nil
+	return strings.Join(list, " ")
+}
+
+// canBrace reports whether str keeps its value once enclosed in braces: its
+// braces have to be balanced and it can not end with a backslash nor contain
+// a backslash-newline that would be substituted.
+func canBrace(str string) bool {
+	var level int
+	for i := 0; i < len(str); i++ {
+		switch str[i] {
+		case '{':
+			level++
+		case '}':
+			level--
+			if level < 0 {
+				return false
+			}
+		case '\\':
+			if i == len(str)-1 || str[i+1] == '\n' {
+				return false
+			}
+			i++
+		}
+	}
+	return level == 0
 }
@@ -0,0 +1,87 @@
+package env
+
+import (
+	"testing"
+)
+
+func TestListRoundTrip(t *testing.T) {
+	data := [][]string{
+		{"a", "b", "c"},
+		{"", "a b", "{}"},
+		{"br[x]", "\"q\"", "$var", "a;b", "x\\y"},
+		{"{a", "b}", "}{", "a\\"},
+		{"line\nbreak", "tab\there", "back\\\nslash"},
+		{"{a b} c", "\\{", "a {b} c"},
+		{"#comment", "#"},
+	}
+	for _, d := range data {
+		var values []Value
+		for _, str := range d {
+			values = append(values, Str(str))
+		}
+		str := ListFrom(values...).String()
+		t.Run(str, func(t *testing.T) {
+			got, err := ToStringList(Str(str))
+			if err != nil {
+				t.Fatalf("parsing error: %s", err)
+			}
+			if len(got) != len(d) {
+				t.Fatalf("length mismatched! want %d, got %d (%q)", len(d), len(got), got)
+			}
+			for i := range d {
+				if got[i] != d[i] {
+					t.Errorf("element %d mismatched! want %q, got %q", i, d[i], got[i])
+				}
+			}
+		})
+	}
+}
+
+func TestDictRoundTrip(t *testing.T) {
+	var (
+		keys   = []string{"br[x]", "a b", "", "{"}
+		values = []Value{Str("\"q\""), Str("$v"), Str("x"), Str("}")}
+		dict   = ZipDict(keys, values)
+	)
+	got, err := Str(dict.String()).ToDict()
+	if err != nil {
+		t.Fatalf("parsing error: %s", err)
+	}
+	d := got.(Dict)
+	if len(d.Keys()) != len(keys) {
+		t.Fatalf("size mismatched! want %d, got %d", len(keys), len(d.Keys()))
+	}
+	for i, k := range keys {
+		if v := d.Get(k); v == nil || v.String() != values[i].String() {
+			t.Errorf("%q: value mismatched! want %q, got %v", k, values[i], v)
+		}
+	}
+}
+
+func TestDictErrors(t *testing.T) {
+	data := []struct {
+		Dict string
+		Keys []string
+		Want string
+	}{
+		{Dict: "a 1 b", Keys: []string{"a"}, Want: "missing value to go with key"},
+		{Dict: "a 1 b 2", Keys: []string{"zz"}, Want: "key \"zz\" not known in dictionary"},
+		{Dict: "a {b 1}", Keys: []string{"a", "c"}, Want: "key \"c\" not known in dictionary"},
+		{Dict: "a 1", Keys: []string{"a", "b"}, Want: "missing value to go with key"},
+		{Dict: "", Keys: []string{"a"}, Want: "key \"a\" not known in dictionary"},
+	}
+	for _, d := range data {
+		t.Run(d.Dict, func(t *testing.T) {
+			dict, err := Str(d.Dict).ToDict()
+			if err == nil {
+				_, err = dict.(Dict).GetPath(d.Keys)
+			}
+			if err == nil {
+				t.Fatalf("expected error")
+			}
+			if err.Error() != d.Want {
+				t.Errorf("error mismatched! want %q, got %q", d.Want, err)
+			}
+		})
+	}
+}
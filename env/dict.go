@@ -0,0 +1,236 @@
+package env
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+)
+
+var ErrMissing = errors.New("missing value to go with key")
+
+type Dict struct {
+	keys   []string
+	values map[string]Value
+}
+
+func ZipDict(keys []string, values []Value) Value {
+	d := createDict()
+	for i := range keys {
+		if i >= len(values) {
+			break
+		}
+		d.put(keys[i], values[i])
+	}
+	return d
+}
+
+func EmptyDict() Value {
+	return createDict()
+}
+
+func createDict() Dict {
+	return Dict{
+		values: make(map[string]Value),
+	}
+}
+
+func (d Dict) Len() int {
+	return len(d.keys)
+}
+
+func (d Dict) Has(k string) bool {
+	_, ok := d.values[k]
+	return ok
+}
+
+func (d Dict) Get(k string) Value {
+	return d.values[k]
+}
+
+func (d Dict) Set(k string, v Value) Value {
+	return d.set(k, v)
+}
+
+func (d Dict) Unset(k string) Value {
+	if !d.Has(k) {
+		return d
+	}
+	x := createDict()
+	for _, n := range d.keys {
+		if n == k {
+			continue
+		}
+		x.keys = append(x.keys, n)
+		x.values[n] = d.values[n]
+	}
+	return x
+}
+
+func (d Dict) Merge(other Dict) Value {
+	x := d.clone()
+	for _, k := range other.keys {
+		x.put(k, other.values[k])
+	}
+	return x
+}
+
+func (d Dict) Keys() []string {
+	ks := make([]string, len(d.keys))
+	copy(ks, d.keys)
+	return ks
+}
+
+func (d Dict) Values() []Value {
+	vs := make([]Value, 0, len(d.keys))
+	for _, k := range d.keys {
+		vs = append(vs, d.values[k])
+	}
+	return vs
+}
+
+func (d Dict) GetPath(keys []string) (Value, error) {
+	var curr Value = d
+	for _, k := range keys {
+		x, err := curr.ToDict()
+		if err != nil {
+			return nil, err
+		}
+		v, ok := x.(Dict).values[k]
+		if !ok {
+			return nil, unknownKey(k)
+		}
+		curr = v
+	}
+	return curr, nil
+}
+
+func (d Dict) SetPath(keys []string, v Value) (Value, error) {
+	if len(keys) == 0 {
+		return v.ToDict()
+	}
+	if len(keys) == 1 {
+		return d.set(keys[0], v), nil
+	}
+	child, ok := d.values[keys[0]]
+	if !ok {
+		child = EmptyDict()
+	}
+	child, err := child.ToDict()
+	if err != nil {
+		return nil, err
+	}
+	child, err = child.(Dict).SetPath(keys[1:], v)
+	if err != nil {
+		return nil, err
+	}
+	return d.set(keys[0], child), nil
+}
+
+func (d Dict) UnsetPath(keys []string) (Value, error) {
+	if len(keys) == 0 {
+		return d, nil
+	}
+	if len(keys) == 1 {
+		return d.Unset(keys[0]), nil
+	}
+	child, ok := d.values[keys[0]]
+	if !ok {
+		return nil, unknownKey(keys[0])
+	}
+	child, err := child.ToDict()
+	if err != nil {
+		return nil, err
+	}
+	child, err = child.(Dict).UnsetPath(keys[1:])
+	if err != nil {
+		return nil, err
+	}
+	return d.set(keys[0], child), nil
+}
+
+func (d Dict) String() string {
+	var list []string
+	for _, k := range d.keys {
+		list = append(list, quote(k), quote(d.values[k].String()))
+	}
+	return joinList(list)
+}
+
+func (d Dict) ToList() (Value, error) {
+	var list []Value
+	for _, k := range d.keys {
+		list = append(list, Str(k), d.values[k])
+	}
+	return ListFrom(list...), nil
+}
+
+func (d Dict) ToArray() (Value, error) {
+	return ZipArr(d.Keys(), d.Values()), nil
+}
+
+func (d Dict) ToDict() (Value, error) {
+	return d, nil
+}
+
+func (d Dict) ToNumber() (Value, error) {
+	return nil, ErrCast
+}
+
+func (d Dict) ToString() (Value, error) {
+	return Str(d.String()), nil
+}
+
+func (d Dict) ToBoolean() (Value, error) {
+	return Bool(len(d.keys) != 0), nil
+}
+
+func (d Dict) set(k string, v Value) Dict {
+	x := d.clone()
+	x.put(k, v)
+	return x
+}
+
+func (d *Dict) put(k string, v Value) {
+	if _, ok := d.values[k]; !ok {
+		d.keys = append(d.keys, k)
+	}
+	d.values[k] = v
+}
+
+func (d Dict) clone() Dict {
+	x := Dict{
+		keys:   make([]string, len(d.keys)),
+		values: make(map[string]Value, len(d.values)),
+	}
+	copy(x.keys, d.keys)
+	for k, v := range d.values {
+		x.values[k] = v
+	}
+	return x
+}
+
+func arrayToDict(a Array) Value {
+	names := a.Names()
+	sort.Strings(names)
+
+	d := createDict()
+	for _, n := range names {
+		d.put(n, a.Get(n))
+	}
+	return d
+}
+
+func listToDict(i List) (Value, error) {
+	if len(i.values)%2 != 0 {
+		return nil, ErrMissing
+	}
+	d := createDict()
+	for j := 0; j < len(i.values); j += 2 {
+		d.put(i.values[j].String(), i.values[j+1])
+	}
+	return d, nil
+}
+
+func unknownKey(k string) error {
+	return fmt.Errorf("key %q not known in dictionary", k)
+}
@@ -9,6 +9,7 @@ var ErrUndefined = errors.New("undefined variable")
 
 type Env struct {
 	values map[string]Value
+	traces map[string][]Trace
 }
 
 func EmptyEnv() *Env {
@@ -40,3 +41,63 @@ func (e *Env) Resolve(n string) (Value, error) {
 	}
 	return v, nil
 }
+
+type Trace struct {
+	Ops []string
+	Cmd Value
+}
+
+func (t Trace) Has(op string) bool {
+	for _, o := range t.Ops {
+		if o == op {
+			return true
+		}
+	}
+	return false
+}
+
+func (t Trace) Equal(ops []string, cmd Value) bool {
+	if t.Cmd.String() != cmd.String() || len(ops) != len(t.Ops) {
+		return false
+	}
+	for _, o := range ops {
+		if !t.Has(o) {
+			return false
+		}
+	}
+	return true
+}
+
+func (e *Env) Traces(n string) []Trace {
+	return e.traces[n]
+}
+
+func (e *Env) AddTrace(n string, t Trace) {
+	if e.traces == nil {
+		e.traces = make(map[string][]Trace)
+	}
+	e.traces[n] = append(e.traces[n], t)
+}
+
+func (e *Env) RemoveTrace(n string, ops []string, cmd Value) bool {
+	list := e.traces[n]
+	for j, t := range list {
+		if !t.Equal(ops, cmd) {
+			continue
+		}
+		list = append(list[:j:j], list[j+1:]...)
+		if len(list) == 0 {
+			delete(e.traces, n)
+		} else {
+			e.traces[n] = list
+		}
+		return true
+	}
+	return false
+}
+
+func (e *Env) ClearTraces(n string) int {
+	c := len(e.traces[n])
+	delete(e.traces, n)
+	return c
+}
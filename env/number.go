@@ -0,0 +1,172 @@
+package env
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// LegacyOctal makes integers written with a leading zero (eg 0755) read as
+// octal numbers like Tcl 8 did instead of decimal numbers.
+//
+// Values are converted to numbers without any reference to an interpreter so
+// the setting is process wide. It may only be set once, before any
+// interpreter is created, and must not be changed afterwards: it is read
+// without synchronization and numbers already cached by values or compiled
+// expressions would keep the old interpretation.
+var LegacyOctal bool
+
+// ParseNumber is the number parser shared by the conversions of values, the
+// expr command and string is. It accepts decimal, 0x, 0o, 0b and 0d integers
+// with optional _ separators between digits, floating point numbers with or
+// without exponent and the Inf and NaN special values.
+func ParseNumber(str string) (Value, error) {
+	var (
+		body = strings.TrimSpace(str)
+		neg  bool
+	)
+	if body != "" && (body[0] == '-' || body[0] == '+') {
+		neg = body[0] == '-'
+		body = body[1:]
+	}
+	switch strings.ToLower(body) {
+	case "inf", "infinity":
+		if neg {
+			return Float(math.Inf(-1)), nil
+		}
+		return Float(math.Inf(1)), nil
+	case "nan":
+		return Float(math.NaN()), nil
+	default:
+	}
+	var (
+		base   = 10
+		digits = body
+		prefix bool
+	)
+	if len(body) > 1 && body[0] == '0' {
+		prefix = true
+		switch body[1] {
+		case 'x', 'X':
+			base, digits = 16, body[2:]
+		case 'o', 'O':
+			base, digits = 8, body[2:]
+		case 'b', 'B':
+			base, digits = 2, body[2:]
+		case 'd', 'D':
+			base, digits = 10, body[2:]
+		default:
+			prefix = LegacyOctal && strings.Trim(body, "0123456789_") == ""
+			if prefix {
+				base = 8
+			}
+		}
+	}
+	if ds, ok := cleanDigits(digits, base, ""); ok {
+		if b, ok := new(big.Int).SetString(ds, base); ok {
+			if neg {
+				b.Neg(b)
+			}
+			return BigInt(b), nil
+		}
+	}
+	if prefix || body == "" || body[0] == '_' || body[0] == 'e' || body[0] == 'E' {
+		return nil, invalidNumber(str)
+	}
+	ds, ok := cleanDigits(body, 10, ".eE+-")
+	if !ok {
+		return nil, invalidNumber(str)
+	}
+	f, err := strconv.ParseFloat(ds, 64)
+	if err != nil {
+		return nil, invalidNumber(str)
+	}
+	if neg {
+		f = -f
+	}
+	return Float(f), nil
+}
+
+// ParseBool accepts the boolean literals true, false, yes, no, on and off
+// (case insensitive) and any number, that is true when not zero.
+func ParseBool(str string) (bool, error) {
+	switch strings.ToLower(strings.TrimSpace(str)) {
+	case "true", "yes", "on":
+		return true, nil
+	case "false", "no", "off":
+		return false, nil
+	default:
+	}
+	n, err := ParseNumber(str)
+	if err != nil {
+		return false, fmt.Errorf("expected boolean value but got %q", str)
+	}
+	x, ok := n.(Number)
+	if !ok || math.IsNaN(x.value) {
+		return false, fmt.Errorf("expected boolean value but got %q", str)
+	}
+	return x.value != 0, nil
+}
+
+// FormatFloat gives the shortest representation of f that reads back as
+// the same real. Like Tcl, it always keeps a decimal point or an exponent so
+// that the string is never taken for an integer.
+func FormatFloat(f float64) string {
+	switch {
+	case math.IsInf(f, 1):
+		return "Inf"
+	case math.IsInf(f, -1):
+		return "-Inf"
+	case math.IsNaN(f):
+		return "NaN"
+	}
+	if a := math.Abs(f); a != 0 && (a < 1e-4 || a >= 1e16) {
+		return strconv.FormatFloat(f, 'e', -1, 64)
+	}
+	str := strconv.FormatFloat(f, 'f', -1, 64)
+	if !strings.ContainsRune(str, '.') {
+		str += ".0"
+	}
+	return str
+}
+
+func cleanDigits(str string, base int, extra string) (string, bool) {
+	if str == "" {
+		return "", false
+	}
+	isDigit := func(c byte) bool {
+		var v int
+		switch {
+		case c >= '0' && c <= '9':
+			v = int(c - '0')
+		case c >= 'a' && c <= 'z':
+			v = int(c-'a') + 10
+		case c >= 'A' && c <= 'Z':
+			v = int(c-'A') + 10
+		default:
+			return false
+		}
+		return v < base
+	}
+	var buf strings.Builder
+	for i := 0; i < len(str); i++ {
+		c := str[i]
+		switch {
+		case isDigit(c) || strings.IndexByte(extra, c) >= 0:
+			buf.WriteByte(c)
+		case c == '_':
+			if i == 0 || i == len(str)-1 || !isDigit(str[i-1]) || !isDigit(str[i+1]) {
+				return "", false
+			}
+		default:
+			return "", false
+		}
+	}
+	return buf.String(), true
+}
+
+func invalidNumber(str string) error {
+	return fmt.Errorf("expected number but got %q", str)
+}
@@ -0,0 +1,37 @@
+package env
+
+import (
+	"math"
+	"testing"
+)
+
+func TestFormatFloat(t *testing.T) {
+	data := []struct {
+		Value float64
+		Want  string
+	}{
+		{Value: 3, Want: "3.0"},
+		{Value: -2, Want: "-2.0"},
+		{Value: 0, Want: "0.0"},
+		{Value: 0.75, Want: "0.75"},
+		{Value: 1.0 / 3, Want: "0.3333333333333333"},
+		{Value: 1e15, Want: "1000000000000000.0"},
+		{Value: 1e16, Want: "1e+16"},
+		{Value: 0.0001, Want: "0.0001"},
+		{Value: 0.00001, Want: "1e-05"},
+		{Value: math.Inf(1), Want: "Inf"},
+		{Value: math.Inf(-1), Want: "-Inf"},
+		{Value: math.NaN(), Want: "NaN"},
+	}
+	for _, d := range data {
+		t.Run(d.Want, func(t *testing.T) {
+			got := FormatFloat(d.Value)
+			if got != d.Want {
+				t.Errorf("results mismatched! want %s, got %s", d.Want, got)
+			}
+			if n, err := ParseNumber(got); err != nil || n.(Number).IsInt() {
+				t.Errorf("%s: not read back as a real", got)
+			}
+		})
+	}
+}
@@ -3,6 +3,7 @@ package env
 import (
 	"errors"
 	"fmt"
+	"math/big"
 	"strconv"
 	"strings"
 
@@ -16,6 +17,7 @@ type Value interface {
 
 	ToList() (Value, error)
 	ToArray() (Value, error)
+	ToDict() (Value, error)
 	ToNumber() (Value, error)
 	ToString() (Value, error)
 	ToBoolean() (Value, error)
@@ -26,7 +28,14 @@ type Array struct {
 }
 
 func ZipArr(keys []string, values []Value) Value {
-	return EmptyArr()
+	arr := EmptyArr().(Array)
+	for i := range keys {
+		if i >= len(values) {
+			break
+		}
+		arr.Set(keys[i], values[i])
+	}
+	return arr
 }
 
 func EmptyArr() Value {
@@ -54,7 +63,7 @@ func (a Array) Unset(n string) {
 func (a Array) Pairs() Value {
 	var list []Value
 	for k, v := range a.values {
-		list = append(list, ListFrom(Str(k), v))
+		list = append(list, Str(k), v)
 	}
 	return ListFrom(list...)
 }
@@ -85,6 +94,10 @@ func (a Array) ToArray() (Value, error) {
 	return a, nil
 }
 
+func (a Array) ToDict() (Value, error) {
+	return arrayToDict(a), nil
+}
+
 func (a Array) ToNumber() (Value, error) {
 	return nil, ErrCast
 }
@@ -99,6 +112,7 @@ func (a Array) ToBoolean() (Value, error) {
 
 type List struct {
 	values []Value
+	cache  *cache
 }
 
 func ListFromStrings(vs []string) Value {
@@ -113,7 +127,9 @@ func ListFrom(vs ...Value) Value {
 	if len(vs) == 0 {
 		return EmptyList()
 	}
-	var i List
+	i := List{
+		cache: new(cache),
+	}
 	i.values = append(i.values, vs...)
 	return i
 }
@@ -164,7 +180,7 @@ func (i List) Reverse() List {
 func (i List) Shuffle() Value {
 	vs := make([]Value, len(i.values))
 	copy(vs, i.values)
-	return ListFrom(slices.Shuffle(i.values)...)
+	return ListFrom(slices.Shuffle(vs)...)
 }
 
 func (i List) Equal(other Value) (bool, error) {
@@ -195,8 +211,10 @@ func (i List) Set(v Value, n int) (Value, error) {
 }
 
 func (i List) Swap(j, k int) List {
-	i.values[j], i.values[k] = i.values[k], i.values[j]
-	return i
+	vs := make([]Value, len(i.values))
+	copy(vs, i.values)
+	vs[j], vs[k] = vs[k], vs[j]
+	return ListFrom(vs...).(List)
 }
 
 func (i List) Shift() (Value, Value) {
@@ -254,11 +272,18 @@ func (i List) Values() []Value {
 }
 
 func (i List) String() string {
+	if i.cache != nil && i.cache.done {
+		return i.cache.str
+	}
 	var list []string
 	for _, v := range i.values {
-		list = append(list, v.String())
+		list = append(list, quote(v.String()))
 	}
-	return strings.Join(list, " ")
+	str := joinList(list)
+	if i.cache != nil {
+		i.cache.str, i.cache.done = str, true
+	}
+	return str
 }
 
 func (i List) Len() int {
@@ -284,6 +309,10 @@ func (i List) ToArray() (Value, error) {
 	return ZipArr(ks, vs), nil
 }
 
+func (i List) ToDict() (Value, error) {
+	return listToDict(i)
+}
+
 func (i List) ToNumber() (Value, error) {
 	return nil, ErrCast
 }
@@ -298,10 +327,14 @@ func (i List) ToBoolean() (Value, error) {
 
 type String struct {
 	value string
+	cache *cache
 }
 
 func Str(str string) Value {
-	return String{value: str}
+	return String{
+		value: str,
+		cache: new(cache),
+	}
 }
 
 func EmptyStr() Value {
@@ -313,7 +346,14 @@ func (s String) String() string {
 }
 
 func (s String) ToList() (Value, error) {
-	return split(s.value)
+	if s.cache != nil && s.cache.list != nil {
+		return s.cache.list, nil
+	}
+	list, err := split(s.value)
+	if err == nil && s.cache != nil {
+		s.cache.list = list
+	}
+	return list, err
 }
 
 func (s String) ToArray() (Value, error) {
@@ -324,12 +364,30 @@ func (s String) ToArray() (Value, error) {
 	return list.ToArray()
 }
 
-func (s String) ToNumber() (Value, error) {
-	n, err := strconv.ParseFloat(s.value, 64)
+func (s String) ToDict() (Value, error) {
+	if s.cache != nil && s.cache.dict != nil {
+		return s.cache.dict, nil
+	}
+	list, err := s.ToList()
 	if err != nil {
 		return nil, err
 	}
-	return Float(n), nil
+	dict, err := list.ToDict()
+	if err == nil && s.cache != nil {
+		s.cache.dict = dict
+	}
+	return dict, err
+}
+
+func (s String) ToNumber() (Value, error) {
+	if s.cache != nil && (s.cache.number != nil || s.cache.err != nil) {
+		return s.cache.number, s.cache.err
+	}
+	val, err := ParseNumber(s.value)
+	if s.cache != nil {
+		s.cache.number, s.cache.err = val, err
+	}
+	return val, err
 }
 
 func (s String) ToString() (Value, error) {
@@ -337,7 +395,11 @@ func (s String) ToString() (Value, error) {
 }
 
 func (s String) ToBoolean() (Value, error) {
-	return Bool(s.value != ""), nil
+	b, err := ParseBool(s.value)
+	if err != nil {
+		return nil, err
+	}
+	return Bool(b), nil
 }
 
 type Boolean struct {
@@ -371,11 +433,15 @@ func (b Boolean) ToArray() (Value, error) {
 	return nil, ErrCast
 }
 
+func (b Boolean) ToDict() (Value, error) {
+	return nil, ErrCast
+}
+
 func (b Boolean) ToNumber() (Value, error) {
 	if !b.value {
 		return Zero(), nil
 	}
-	return Float(1), nil
+	return Int(1), nil
 }
 
 func (b Boolean) ToString() (Value, error) {
@@ -388,22 +454,71 @@ func (b Boolean) ToBoolean() (Value, error) {
 
 type Number struct {
 	value float64
+	whole bool
+	big   *big.Int
 }
 
+const maxExact = 1 << 53
+
 func Float(f float64) Value {
 	return Number{value: f}
 }
 
 func Int(i int64) Value {
-	return Float(float64(i))
+	n := Number{
+		value: float64(i),
+		whole: true,
+	}
+	if i > maxExact || i < -maxExact {
+		n.big = big.NewInt(i)
+	}
+	return n
+}
+
+func BigInt(b *big.Int) Value {
+	if b.IsInt64() {
+		return Int(b.Int64())
+	}
+	f, _ := new(big.Float).SetInt(b).Float64()
+	return Number{
+		value: f,
+		whole: true,
+		big:   new(big.Int).Set(b),
+	}
 }
 
 func Zero() Value {
-	return Float(0)
+	return Int(0)
+}
+
+func (n Number) IsInt() bool {
+	return n.whole
+}
+
+func (n Number) Int64() (int64, bool) {
+	if n.big != nil {
+		return n.big.Int64(), n.big.IsInt64()
+	}
+	return int64(n.value), n.whole
+}
+
+func (n Number) Big() *big.Int {
+	if n.big != nil {
+		return new(big.Int).Set(n.big)
+	}
+	b, _ := big.NewFloat(n.value).Int(nil)
+	return b
 }
 
 func (n Number) String() string {
-	return strconv.FormatFloat(n.value, 'g', -1, 64)
+	switch {
+	case n.big != nil:
+		return n.big.String()
+	case n.whole:
+		return strconv.FormatInt(int64(n.value), 10)
+	default:
+		return FormatFloat(n.value)
+	}
 }
 
 func (n Number) ToList() (Value, error) {
@@ -414,15 +529,18 @@ func (n Number) ToArray() (Value, error) {
 	return nil, ErrCast
 }
 
+func (n Number) ToDict() (Value, error) {
+	return nil, ErrCast
+}
+
 func (n Number) ToNumber() (Value, error) {
 	return n, nil
 }
 
 func (n Number) ToString() (Value, error) {
-	str := strconv.FormatFloat(n.value, 'g', -1, 64)
-	return Str(str), nil
+	return Str(n.String()), nil
 }
 
 func (n Number) ToBoolean() (Value, error) {
-	return Bool(int(n.value) == 0), nil
+	return Bool(n.value != 0), nil
 }
@@ -0,0 +1,74 @@
+package expr
+
+import (
+	"container/list"
+	"sync"
+)
+
+const DefaultCacheSize = 512
+
+func Compile(str string) (Expression, error) {
+	p, err := Parse(str)
+	if err != nil {
+		return nil, err
+	}
+	return p.Parse()
+}
+
+type entry struct {
+	key  string
+	expr Expression
+}
+
+type Cache struct {
+	mu    sync.Mutex
+	size  int
+	list  *list.List
+	items map[string]*list.Element
+}
+
+func NewCache(size int) *Cache {
+	if size <= 0 {
+		size = DefaultCacheSize
+	}
+	return &Cache{
+		size:  size,
+		list:  list.New(),
+		items: make(map[string]*list.Element),
+	}
+}
+
+func (c *Cache) Get(str string) (Expression, error) {
+	c.mu.Lock()
+	if e, ok := c.items[str]; ok {
+		c.list.MoveToFront(e)
+		c.mu.Unlock()
+		return e.Value.(entry).expr, nil
+	}
+	c.mu.Unlock()
+
+	expr, err := Compile(str)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if e, ok := c.items[str]; ok {
+		c.list.MoveToFront(e)
+		return e.Value.(entry).expr, nil
+	}
+	c.items[str] = c.list.PushFront(entry{key: str, expr: expr})
+	if c.list.Len() > c.size {
+		e := c.list.Back()
+		c.list.Remove(e)
+		delete(c.items, e.Value.(entry).key)
+	}
+	return expr, nil
+}
+
+func (c *Cache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.list.Len()
+}
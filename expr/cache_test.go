@@ -0,0 +1,115 @@
+package expr
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/midbel/gotcl/env"
+)
+
+type testEnv map[string]env.Value
+
+func (e testEnv) Resolve(name string) (env.Value, error) {
+	v, ok := e[name]
+	if !ok {
+		return nil, fmt.Errorf("%s: undefined variable", name)
+	}
+	return v, nil
+}
+
+func TestCacheLen(t *testing.T) {
+	c := NewCache(4)
+	if n := c.Len(); n != 0 {
+		t.Fatalf("length mismatched! want 0, got %d", n)
+	}
+	for _, str := range []string{"1 + 1", "2 * 3", "1 + 1"} {
+		if _, err := c.Get(str); err != nil {
+			t.Fatalf("%s: unexpected error: %s", str, err)
+		}
+	}
+	if n := c.Len(); n != 2 {
+		t.Errorf("length mismatched! want 2, got %d", n)
+	}
+	if _, err := c.Get("1 +"); err == nil {
+		t.Errorf("expected error")
+	}
+	if n := c.Len(); n != 2 {
+		t.Errorf("invalid expression cached! want 2, got %d", n)
+	}
+}
+
+func TestCacheEviction(t *testing.T) {
+	c := NewCache(2)
+	for _, str := range []string{"1", "2", "3"} {
+		if _, err := c.Get(str); err != nil {
+			t.Fatalf("%s: unexpected error: %s", str, err)
+		}
+	}
+	if n := c.Len(); n != 2 {
+		t.Errorf("length mismatched! want 2, got %d", n)
+	}
+	if _, ok := c.items["1"]; ok {
+		t.Errorf("oldest expression not evicted")
+	}
+	for _, str := range []string{"2", "3"} {
+		if _, ok := c.items[str]; !ok {
+			t.Errorf("%s: expression evicted", str)
+		}
+	}
+}
+
+func TestCacheRecency(t *testing.T) {
+	c := NewCache(2)
+	for _, str := range []string{"1", "2", "1", "3"} {
+		if _, err := c.Get(str); err != nil {
+			t.Fatalf("%s: unexpected error: %s", str, err)
+		}
+	}
+	if _, ok := c.items["2"]; ok {
+		t.Errorf("least recently used expression not evicted")
+	}
+	for _, str := range []string{"1", "3"} {
+		if _, ok := c.items[str]; !ok {
+			t.Errorf("%s: expression evicted", str)
+		}
+	}
+}
+
+func TestCacheDefaultSize(t *testing.T) {
+	c := NewCache(0)
+	if c.size != DefaultCacheSize {
+		t.Errorf("size mismatched! want %d, got %d", DefaultCacheSize, c.size)
+	}
+}
+
+const benchExpr = "$x * 2 + $y - 3 / 1.5 + ($x << 2) - ($y % 7)"
+
+var benchEnv = testEnv{
+	"x": env.Int(42),
+	"y": env.Int(17),
+}
+
+func BenchmarkEvalUncached(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		e, err := Compile(benchExpr)
+		if err != nil {
+			b.Fatal(err)
+		}
+		if _, err := e.Eval(benchEnv); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkEvalCached(b *testing.B) {
+	c := NewCache(DefaultCacheSize)
+	for i := 0; i < b.N; i++ {
+		e, err := c.Get(benchExpr)
+		if err != nil {
+			b.Fatal(err)
+		}
+		if _, err := e.Eval(benchEnv); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
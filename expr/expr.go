@@ -1,6 +1,9 @@
 package expr
 
 import (
+	"fmt"
+	"strings"
+
 	"github.com/midbel/gotcl/env"
 	"github.com/midbel/gotcl/expr/types"
 	"github.com/midbel/gotcl/word"
@@ -10,6 +13,14 @@ type Env interface {
 	Resolve(string) (env.Value, error)
 }
 
+type Caller interface {
+	Call(string, []env.Value) (env.Value, error)
+}
+
+type Runner interface {
+	ExecuteValue(env.Value) (env.Value, error)
+}
+
 type Expression interface {
 	Eval(Env) (types.Value, error)
 }
@@ -57,6 +68,92 @@ func (i Identifier) Eval(env Env) (types.Value, error) {
 	return types.AsValue(str)
 }
 
+type Element struct {
+	Name  string
+	Index Expression
+}
+
+func (e Element) Eval(env Env) (types.Value, error) {
+	key, err := e.Index.Eval(env)
+	if err != nil {
+		return nil, err
+	}
+	str, err := env.Resolve(fmt.Sprintf("%s(%s)", e.Name, key))
+	if err != nil {
+		return nil, err
+	}
+	return types.AsValue(str)
+}
+
+type String struct {
+	Value string
+}
+
+func (s String) Eval(_ Env) (types.Value, error) {
+	return types.StrValue(s.Value), nil
+}
+
+type Quote struct {
+	Parts []Expression
+}
+
+func (q Quote) Eval(e Env) (types.Value, error) {
+	var str strings.Builder
+	for _, p := range q.Parts {
+		v, err := p.Eval(e)
+		if err != nil {
+			return nil, err
+		}
+		str.WriteString(v.String())
+	}
+	return types.StrValue(str.String()), nil
+}
+
+type Script struct {
+	Value env.Value
+}
+
+func (s Script) Eval(e Env) (types.Value, error) {
+	run, ok := e.(Runner)
+	if !ok {
+		return nil, fmt.Errorf("command substitution not supported")
+	}
+	res, err := run.ExecuteValue(s.Value)
+	if err != nil {
+		return nil, err
+	}
+	return types.AsValue(res)
+}
+
+type Call struct {
+	Name string
+	Args []Expression
+}
+
+func (c Call) Eval(e Env) (types.Value, error) {
+	call, ok := e.(Caller)
+	if !ok {
+		return nil, fmt.Errorf("%s: math function can not be called", c.Name)
+	}
+	var args []env.Value
+	for _, a := range c.Args {
+		v, err := a.Eval(e)
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, types.AsEnv(v))
+	}
+	name := c.Name
+	if !strings.Contains(name, "::") {
+		name = "::tcl::mathfunc::" + name
+	}
+	res, err := call.Call(name, args)
+	if err != nil {
+		return nil, err
+	}
+	return types.AsValue(res)
+}
+
 type Prefix struct {
 	Op    rune
 	Right Expression
@@ -70,6 +167,8 @@ func (p Prefix) Eval(env Env) (types.Value, error) {
 	switch p.Op {
 	case word.Not:
 		return v.Not()
+	case word.Add:
+		return types.AsNumber(v)
 	case word.Sub:
 		return v.Rev()
 	case word.Bnot:
@@ -86,6 +185,9 @@ type Infix struct {
 }
 
 func (i Infix) Eval(env Env) (types.Value, error) {
+	if i.Op == word.And || i.Op == word.Or {
+		return i.logical(env)
+	}
 	left, err := i.Left.Eval(env)
 	if err != nil {
 		return nil, err
@@ -95,10 +197,30 @@ func (i Infix) Eval(env Env) (types.Value, error) {
 		return nil, err
 	}
 	switch i.Op {
-	case word.And:
-		return left.And(right)
-	case word.Or:
-		return left.Or(right)
+	case word.StrEq:
+		return types.BoolValue(left.String() == right.String()), nil
+	case word.StrNe:
+		return types.BoolValue(left.String() != right.String()), nil
+	case word.StrLt:
+		return types.BoolValue(left.String() < right.String()), nil
+	case word.StrLe:
+		return types.BoolValue(left.String() <= right.String()), nil
+	case word.StrGt:
+		return types.BoolValue(left.String() > right.String()), nil
+	case word.StrGe:
+		return types.BoolValue(left.String() >= right.String()), nil
+	case word.In:
+		return contains(left, right)
+	case word.Ni:
+		ok, err := contains(left, right)
+		if err != nil {
+			return nil, err
+		}
+		return ok.Not()
+	default:
+	}
+	left, right = types.Coerce(left, right)
+	switch i.Op {
 	case word.Add:
 		return left.Add(right)
 	case word.Sub:
@@ -137,3 +259,43 @@ func (i Infix) Eval(env Env) (types.Value, error) {
 		return nil, nil
 	}
 }
+
+func (i Infix) logical(env Env) (types.Value, error) {
+	left, err := i.Left.Eval(env)
+	if err != nil {
+		return nil, err
+	}
+	ok, err := types.AsBool(left)
+	if err != nil {
+		return nil, err
+	}
+	if ok == (i.Op == word.Or) {
+		return types.BoolValue(ok), nil
+	}
+	right, err := i.Right.Eval(env)
+	if err != nil {
+		return nil, err
+	}
+	ok, err = types.AsBool(right)
+	if err != nil {
+		return nil, err
+	}
+	return types.BoolValue(ok), nil
+}
+
+func contains(value, list types.Value) (types.Value, error) {
+	vs, err := env.ToStringList(env.Str(list.String()))
+	if err != nil {
+		return nil, err
+	}
+	var (
+		str = value.String()
+		ok  bool
+	)
+	for _, v := range vs {
+		if ok = v == str; ok {
+			break
+		}
+	}
+	return types.BoolValue(ok), nil
+}
@@ -0,0 +1,216 @@
+package expr
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/midbel/gotcl/env"
+)
+
+type scriptEnv struct {
+	testEnv
+	calls []string
+}
+
+func (e *scriptEnv) ExecuteValue(v env.Value) (env.Value, error) {
+	e.calls = append(e.calls, v.String())
+	if v.String() == "boom" {
+		return nil, fmt.Errorf("boom")
+	}
+	return v, nil
+}
+
+func TestOperators(t *testing.T) {
+	data := []struct {
+		Expr string
+		Want string
+	}{
+		{Expr: "2 + 3 * 4", Want: "14"},
+		{Expr: "(2 + 3) * 4", Want: "20"},
+		{Expr: "10 - 4 - 3", Want: "3"},
+		{Expr: "100 / 10 / 5", Want: "2"},
+		{Expr: "2 ** 3 ** 2", Want: "512"},
+		{Expr: "2 * 3 ** 2", Want: "18"},
+		{Expr: "-2 ** 2", Want: "4"},
+		{Expr: "2 ** -1", Want: "0"},
+		{Expr: "-7 / 2", Want: "-4"},
+		{Expr: "7 / -2", Want: "-4"},
+		{Expr: "-7 % 2", Want: "1"},
+		{Expr: "7 % -2", Want: "-1"},
+		{Expr: "7 / 2.0", Want: "3.5"},
+		{Expr: "1 << 2 + 1", Want: "8"},
+		{Expr: "-16 >> 2", Want: "-4"},
+		{Expr: "1 + 2 < 4", Want: "1"},
+		{Expr: "3 < 2 == 0", Want: "1"},
+		{Expr: "6 & 3 == 2", Want: "0"},
+		{Expr: "1 | 2 ^ 3 & 6", Want: "1"},
+		{Expr: "~0 & 7", Want: "7"},
+		{Expr: "!0 && !0", Want: "1"},
+		{Expr: "1 || 0 && 0", Want: "1"},
+		{Expr: "0 && 1 || 1", Want: "1"},
+		{Expr: "1 < 2 && 3 > 2", Want: "1"},
+		{Expr: "1 == 1 || 1 / 0", Want: "1"},
+		{Expr: "2 && 3", Want: "1"},
+		{Expr: "0 || 0.0", Want: "0"},
+		{Expr: "1 ? 2 : 3", Want: "2"},
+		{Expr: "0 ? 1 : 2 ? 3 : 4", Want: "3"},
+		{Expr: "1 < 2 ? 10 : 20", Want: "10"},
+		{Expr: "\"a\" eq \"a\" && 1", Want: "1"},
+		{Expr: "\"abc\" lt \"abd\"", Want: "1"},
+		{Expr: "\"b\" in {a b c}", Want: "1"},
+		{Expr: "\"d\" ni {a b c}", Want: "1"},
+		{Expr: "2 eq 1 == 0", Want: "0"},
+		{Expr: "2 in {2} ne 2", Want: "0"},
+		{Expr: "\"b\" in {a b} eq 1", Want: "0"},
+		{Expr: "+5", Want: "5"},
+		{Expr: "+5 - 2", Want: "3"},
+		{Expr: "-+2", Want: "-2"},
+		{Expr: "+2.5", Want: "2.5"},
+		{Expr: "+\"0x10\"", Want: "16"},
+		{Expr: "$x * 2 + $y", Want: "101"},
+		{Expr: "$zero != 0 && 10 / $zero > 1", Want: "0"},
+		{Expr: "$zero == 0 || 10 / $zero > 1", Want: "1"},
+		{Expr: "9223372036854775807 + 1", Want: "9223372036854775808"},
+		{Expr: "0xff & 0x0F", Want: "15"},
+		{Expr: "0b1010 + 0o17 + 0d3", Want: "28"},
+		{Expr: "1_000 * 2", Want: "2000"},
+		{Expr: "1.5e3 + 2E-1", Want: "1500.2"},
+		{Expr: ".5 + 5.", Want: "5.5"},
+		{Expr: "-.25*4", Want: "-1.0"},
+		{Expr: "1.e2", Want: "100.0"},
+		{Expr: "2e-1+1", Want: "1.2"},
+		{Expr: "0x1e+1", Want: "31"},
+		{Expr: "Inf > 1e308", Want: "1"},
+		{Expr: "-Inf", Want: "-Inf"},
+		{Expr: "true && yes", Want: "1"},
+		{Expr: "off || no", Want: "0"},
+		{Expr: "\"0x10\" + 1", Want: "17"},
+	}
+	for _, d := range data {
+		t.Run(d.Expr, func(t *testing.T) {
+			e, err := Compile(d.Expr)
+			if err != nil {
+				t.Fatalf("parsing error: %s", err)
+			}
+			got, err := e.Eval(operatorEnv())
+			if err != nil {
+				t.Fatalf("evaluation error: %s", err)
+			}
+			if got.String() != d.Want {
+				t.Errorf("results mismatched! want %s, got %s", d.Want, got)
+			}
+		})
+	}
+}
+
+func TestOperatorErrors(t *testing.T) {
+	data := []string{
+		"10 / $zero",
+		"10 % 0",
+		"0 ** -1",
+		"1 << -1",
+		"1 && \"foo\"",
+		"$undefined || 1",
+		"+\"foo\"",
+		"+true",
+	}
+	for _, str := range data {
+		t.Run(str, func(t *testing.T) {
+			e, err := Compile(str)
+			if err != nil {
+				t.Fatalf("parsing error: %s", err)
+			}
+			if got, err := e.Eval(operatorEnv()); err == nil {
+				t.Errorf("expected error, got %s", got)
+			}
+		})
+	}
+}
+
+func TestLiteralErrors(t *testing.T) {
+	data := []string{
+		"0x + 1",
+		"1__0 + 1",
+		"1_ + 1",
+		"0b102 + 1",
+		"0o8",
+		"0x1.5",
+		"1.5.2",
+		"1e",
+		"1e+",
+		"..5",
+		".5.",
+		"5.e",
+	}
+	for _, str := range data {
+		t.Run(str, func(t *testing.T) {
+			if _, err := Compile(str); err == nil {
+				t.Errorf("expected error")
+			}
+		})
+	}
+}
+
+func TestSyntaxErrors(t *testing.T) {
+	data := []string{
+		"1 2",
+		"$x$y",
+		"(1+2))",
+		"1 + 2 3",
+		"max(1, 2) 3",
+	}
+	for _, str := range data {
+		t.Run(str, func(t *testing.T) {
+			if _, err := Compile(str); err == nil {
+				t.Errorf("expected error")
+			}
+		})
+	}
+}
+
+func TestShortCircuit(t *testing.T) {
+	data := []struct {
+		Expr  string
+		Want  string
+		Calls int
+	}{
+		{Expr: "0 && [boom]", Want: "0", Calls: 0},
+		{Expr: "1 || [boom]", Want: "1", Calls: 0},
+		{Expr: "1 && [1]", Want: "1", Calls: 1},
+		{Expr: "0 || [0]", Want: "0", Calls: 1},
+		{Expr: "[0] && [boom]", Want: "0", Calls: 1},
+		{Expr: "[1] || [boom] || [boom]", Want: "1", Calls: 1},
+		{Expr: "[0] || [1] && [1]", Want: "1", Calls: 3},
+		{Expr: "1 ? [1] : [boom]", Want: "1", Calls: 1},
+		{Expr: "0 ? [boom] : [0]", Want: "0", Calls: 1},
+	}
+	for _, d := range data {
+		t.Run(d.Expr, func(t *testing.T) {
+			e, err := Compile(d.Expr)
+			if err != nil {
+				t.Fatalf("parsing error: %s", err)
+			}
+			env := scriptEnv{
+				testEnv: operatorEnv(),
+			}
+			got, err := e.Eval(&env)
+			if err != nil {
+				t.Fatalf("evaluation error: %s", err)
+			}
+			if got.String() != d.Want {
+				t.Errorf("results mismatched! want %s, got %s", d.Want, got)
+			}
+			if len(env.calls) != d.Calls {
+				t.Errorf("scripts mismatched! want %d, got %d (%q)", d.Calls, len(env.calls), env.calls)
+			}
+		})
+	}
+}
+
+func operatorEnv() testEnv {
+	return testEnv{
+		"x":    env.Int(42),
+		"y":    env.Int(17),
+		"zero": env.Int(0),
+	}
+}
@@ -2,9 +2,9 @@ package expr
 
 import (
 	"fmt"
-	"strconv"
 	"strings"
 
+	"github.com/midbel/gotcl/env"
 	"github.com/midbel/gotcl/expr/types"
 	"github.com/midbel/gotcl/word"
 )
@@ -17,23 +17,26 @@ const (
 	BitOr
 	BitXor
 	BitAnd
+	Membership
+	StrEquality
 	Equality
 	Relational
 	Shift
 	Additive
 	Multiplicative
+	Power
 	Unary
 )
 
 var bindings = map[rune]int{
-	word.And:     Relational,
-	word.Or:      Relational,
+	word.And:     LogicalAnd,
+	word.Or:      LogicalOr,
 	word.Add:     Additive,
 	word.Sub:     Additive,
 	word.Mul:     Multiplicative,
 	word.Div:     Multiplicative,
 	word.Mod:     Multiplicative,
-	word.Pow:     Multiplicative,
+	word.Pow:     Power,
 	word.Eq:      Equality,
 	word.Ne:      Equality,
 	word.Gt:      Relational,
@@ -46,6 +49,14 @@ var bindings = map[rune]int{
 	word.Bor:     BitOr,
 	word.Bxor:    BitXor,
 	word.Ternary: Condition,
+	word.StrEq:   StrEquality,
+	word.StrNe:   StrEquality,
+	word.In:      Membership,
+	word.Ni:      Membership,
+	word.StrLt:   Relational,
+	word.StrLe:   Relational,
+	word.StrGt:   Relational,
+	word.StrGe:   Relational,
 }
 
 type Parser struct {
@@ -69,12 +80,17 @@ func Parse(str string) (*Parser, error) {
 		infix:  make(map[rune]func(Expression) (Expression, error)),
 	}
 	p.registerPrefix(word.Not, p.parsePrefix)
+	p.registerPrefix(word.Add, p.parsePrefix)
 	p.registerPrefix(word.Sub, p.parsePrefix)
 	p.registerPrefix(word.Bnot, p.parsePrefix)
 	p.registerPrefix(word.Int, p.parseNumber)
 	p.registerPrefix(word.Float, p.parseNumber)
 	p.registerPrefix(word.Variable, p.parseVariable)
 	p.registerPrefix(word.Paren, p.parseGroup)
+	p.registerPrefix(word.Literal, p.parseCall)
+	p.registerPrefix(word.Quote, p.parseQuote)
+	p.registerPrefix(word.Block, p.parseString)
+	p.registerPrefix(word.Script, p.parseScript)
 	p.registerInfix(word.And, p.parseInfix)
 	p.registerInfix(word.Or, p.parseInfix)
 	p.registerInfix(word.Add, p.parseInfix)
@@ -94,6 +110,14 @@ func Parse(str string) (*Parser, error) {
 	p.registerInfix(word.Band, p.parseInfix)
 	p.registerInfix(word.Bor, p.parseInfix)
 	p.registerInfix(word.Bxor, p.parseInfix)
+	p.registerInfix(word.StrEq, p.parseInfix)
+	p.registerInfix(word.StrNe, p.parseInfix)
+	p.registerInfix(word.StrLt, p.parseInfix)
+	p.registerInfix(word.StrLe, p.parseInfix)
+	p.registerInfix(word.StrGt, p.parseInfix)
+	p.registerInfix(word.StrGe, p.parseInfix)
+	p.registerInfix(word.In, p.parseInfix)
+	p.registerInfix(word.Ni, p.parseInfix)
 	p.registerInfix(word.Ternary, p.parseTernary)
 
 	p.next()
@@ -102,7 +126,14 @@ func Parse(str string) (*Parser, error) {
 }
 
 func (p *Parser) Parse() (Expression, error) {
-	return p.parseExpression(Lowest)
+	expr, err := p.parseExpression(Lowest)
+	if err != nil {
+		return nil, err
+	}
+	if p.peek.Type != word.EOF {
+		return nil, fmt.Errorf("syntax error: unexpected word: %s", p.peek)
+	}
+	return expr, nil
 }
 
 func (p *Parser) parseExpression(binding int) (Expression, error) {
@@ -145,44 +176,139 @@ func (p *Parser) peekPower() int {
 }
 
 func (p *Parser) parseGroup() (Expression, error) {
+	if !p.isOpen(p.curr) {
+		return nil, fmt.Errorf("syntax error: unexpected closing parenthese")
+	}
 	p.next()
 	expr, err := p.parseExpression(Lowest)
 	if err != nil {
 		return nil, err
 	}
-	if p.peek.Type != word.Paren {
+	if !p.isClose(p.peek) {
 		return nil, fmt.Errorf("syntax error: missing closing parenthese")
 	}
 	p.next()
 	return expr, nil
 }
 
-func (p *Parser) parseNumber() (Expression, error) {
-	var val types.Value
-	switch p.curr.Type {
-	case word.Int:
-		v, err := strconv.ParseInt(p.curr.Literal, 0, 64)
+func (p *Parser) parseCall() (Expression, error) {
+	if !p.isOpen(p.peek) {
+		return p.parseConstant()
+	}
+	c := Call{
+		Name: p.curr.Literal,
+	}
+	p.next()
+	if !p.isOpen(p.curr) {
+		return nil, fmt.Errorf("syntax error: %s: missing opening parenthese", c.Name)
+	}
+	p.next()
+	if p.isClose(p.curr) {
+		return c, nil
+	}
+	for {
+		arg, err := p.parseExpression(Lowest)
 		if err != nil {
 			return nil, err
 		}
-		val = types.IntValue(v)
-	case word.Float:
-		v, err := strconv.ParseFloat(p.curr.Literal, 64)
-		if err != nil {
-			return nil, err
+		c.Args = append(c.Args, arg)
+		p.next()
+		switch {
+		case p.curr.Type == word.Comma:
+			p.next()
+		case p.isClose(p.curr):
+			return c, nil
+		default:
+			return nil, fmt.Errorf("syntax error: %s: missing closing parenthese", c.Name)
 		}
-		val = types.RealValue(v)
-	default:
-		return nil, fmt.Errorf("unsupported word type: %s", p.curr)
+	}
+}
+
+func (p *Parser) parseConstant() (Expression, error) {
+	str := p.curr.Literal
+	if _, err := env.ParseNumber(str); err == nil {
+		return p.parseNumber()
+	}
+	if _, err := env.ParseBool(str); err == nil {
+		return String{Value: str}, nil
+	}
+	return nil, fmt.Errorf("syntax error: %s: missing opening parenthese", str)
+}
+
+func (p *Parser) parseNumber() (Expression, error) {
+	n, err := env.ParseNumber(p.curr.Literal)
+	if err != nil {
+		return nil, err
+	}
+	val, err := types.AsValue(n)
+	if err != nil {
+		return nil, err
 	}
 	return Number{Value: val}, nil
 }
 
-func (p *Parser) parseVariable() (Expression, error) {
-	i := Identifier{
+func (p *Parser) parseString() (Expression, error) {
+	s := String{
 		Value: p.curr.Literal,
 	}
-	return i, nil
+	return s, nil
+}
+
+func (p *Parser) parseQuote() (Expression, error) {
+	return parseParts(p.curr.Literal)
+}
+
+func parseParts(str string) (Expression, error) {
+	list, err := word.Split(str)
+	if err != nil {
+		return nil, err
+	}
+	var q Quote
+	for _, w := range list {
+		var part Expression
+		switch w.Type {
+		case word.Literal:
+			part = String{Value: w.Literal}
+		case word.Variable:
+			part, err = parseIdentifier(w.Literal)
+			if err != nil {
+				return nil, err
+			}
+		case word.Script:
+			part = Script{Value: env.Str(w.Literal)}
+		default:
+			return nil, fmt.Errorf("unsupported word type: %s", w)
+		}
+		q.Parts = append(q.Parts, part)
+	}
+	return q, nil
+}
+
+func (p *Parser) parseScript() (Expression, error) {
+	s := Script{
+		Value: env.Str(p.curr.Literal),
+	}
+	return s, nil
+}
+
+func (p *Parser) parseVariable() (Expression, error) {
+	return parseIdentifier(p.curr.Literal)
+}
+
+func parseIdentifier(str string) (Expression, error) {
+	name, key, ok := word.SplitIndex(str)
+	if !ok || !strings.ContainsAny(key, "$[") {
+		return Identifier{Value: str}, nil
+	}
+	index, err := parseParts(key)
+	if err != nil {
+		return nil, err
+	}
+	e := Element{
+		Name:  name,
+		Index: index,
+	}
+	return e, nil
 }
 
 func (p *Parser) parsePrefix() (Expression, error) {
@@ -204,6 +330,9 @@ func (p *Parser) parseInfix(left Expression) (Expression, error) {
 		Op:   p.curr.Type,
 	}
 	pow := p.currPower()
+	if i.Op == word.Pow {
+		pow--
+	}
 	p.next()
 	right, err := p.parseExpression(pow)
 	if err != nil {
@@ -243,6 +372,14 @@ func (p *Parser) registerInfix(op rune, fn func(Expression) (Expression, error))
 	p.infix[op] = fn
 }
 
+func (p *Parser) isOpen(w word.Word) bool {
+	return w.Type == word.Paren && w.Literal == "("
+}
+
+func (p *Parser) isClose(w word.Word) bool {
+	return w.Type == word.Paren && w.Literal == ")"
+}
+
 func (p *Parser) next() {
 	p.curr = p.peek
 	p.peek = p.scan.Tokenize()
@@ -0,0 +1,243 @@
+package types
+
+import (
+	"fmt"
+	"math/big"
+)
+
+type Big struct {
+	value *big.Int
+}
+
+func BigValue(b *big.Int) Value {
+	if b.IsInt64() {
+		return IntValue(b.Int64())
+	}
+	return Big{
+		value: b,
+	}
+}
+
+func (b Big) Bool() (Value, error) {
+	return BoolValue(b.value.Sign() != 0), nil
+}
+
+func (b Big) Int() (Value, error) {
+	return b, nil
+}
+
+func (b Big) Double() (Value, error) {
+	f, _ := new(big.Float).SetInt(b.value).Float64()
+	return RealValue(f), nil
+}
+
+func (b Big) String() string {
+	return b.value.String()
+}
+
+func (b Big) Not() (Value, error) {
+	return BoolValue(b.value.Sign() == 0), nil
+}
+
+func (b Big) Rev() (Value, error) {
+	return BigValue(new(big.Int).Neg(b.value)), nil
+}
+
+func (b Big) Add(other Value) (Value, error) {
+	return b.apply(other, func(x, y *big.Int) (*big.Int, error) {
+		return new(big.Int).Add(x, y), nil
+	}, Value.Add)
+}
+
+func (b Big) Sub(other Value) (Value, error) {
+	return b.apply(other, func(x, y *big.Int) (*big.Int, error) {
+		return new(big.Int).Sub(x, y), nil
+	}, Value.Sub)
+}
+
+func (b Big) Div(other Value) (Value, error) {
+	return b.apply(other, func(x, y *big.Int) (*big.Int, error) {
+		if y.Sign() == 0 {
+			return nil, ErrZero
+		}
+		q, _ := floorDivMod(x, y)
+		return q, nil
+	}, Value.Div)
+}
+
+func (b Big) Mod(other Value) (Value, error) {
+	return b.apply(other, func(x, y *big.Int) (*big.Int, error) {
+		if y.Sign() == 0 {
+			return nil, ErrZero
+		}
+		_, r := floorDivMod(x, y)
+		return r, nil
+	}, Value.Mod)
+}
+
+func (b Big) Mul(other Value) (Value, error) {
+	return b.apply(other, func(x, y *big.Int) (*big.Int, error) {
+		return new(big.Int).Mul(x, y), nil
+	}, Value.Mul)
+}
+
+func (b Big) Pow(other Value) (Value, error) {
+	return b.apply(other, func(x, y *big.Int) (*big.Int, error) {
+		if y.Sign() >= 0 {
+			return new(big.Int).Exp(x, y, nil), nil
+		}
+		switch {
+		case x.Sign() == 0:
+			return nil, fmt.Errorf("exponentiation of zero by negative power")
+		case x.CmpAbs(big.NewInt(1)) != 0:
+			return new(big.Int), nil
+		case x.Sign() < 0 && y.Bit(0) == 1:
+			return big.NewInt(-1), nil
+		default:
+			return big.NewInt(1), nil
+		}
+	}, Value.Pow)
+}
+
+func (b Big) And(other Value) (Value, error) {
+	r, _ := b.Bool()
+	return r.And(other)
+}
+
+func (b Big) Or(other Value) (Value, error) {
+	r, _ := b.Bool()
+	return r.Or(other)
+}
+
+func (b Big) Eq(other Value) (Value, error) {
+	return b.compare(other, Value.Eq, func(c int) bool { return c == 0 })
+}
+
+func (b Big) Ne(other Value) (Value, error) {
+	return b.compare(other, Value.Ne, func(c int) bool { return c != 0 })
+}
+
+func (b Big) Lt(other Value) (Value, error) {
+	return b.compare(other, Value.Lt, func(c int) bool { return c < 0 })
+}
+
+func (b Big) Le(other Value) (Value, error) {
+	return b.compare(other, Value.Le, func(c int) bool { return c <= 0 })
+}
+
+func (b Big) Gt(other Value) (Value, error) {
+	return b.compare(other, Value.Gt, func(c int) bool { return c > 0 })
+}
+
+func (b Big) Ge(other Value) (Value, error) {
+	return b.compare(other, Value.Ge, func(c int) bool { return c >= 0 })
+}
+
+func (b Big) Bnot() (Value, error) {
+	return BigValue(new(big.Int).Not(b.value)), nil
+}
+
+func (b Big) Lshift(other Value) (Value, error) {
+	return b.shift(other, func(x *big.Int, n uint) *big.Int {
+		return new(big.Int).Lsh(x, n)
+	})
+}
+
+func (b Big) Rshift(other Value) (Value, error) {
+	return b.shift(other, func(x *big.Int, n uint) *big.Int {
+		return new(big.Int).Rsh(x, n)
+	})
+}
+
+func (b Big) Band(other Value) (Value, error) {
+	return b.bitwise(other, func(x, y *big.Int) *big.Int {
+		return new(big.Int).And(x, y)
+	})
+}
+
+func (b Big) Bor(other Value) (Value, error) {
+	return b.bitwise(other, func(x, y *big.Int) *big.Int {
+		return new(big.Int).Or(x, y)
+	})
+}
+
+func (b Big) Bxor(other Value) (Value, error) {
+	return b.bitwise(other, func(x, y *big.Int) *big.Int {
+		return new(big.Int).Xor(x, y)
+	})
+}
+
+func (b Big) apply(other Value, do func(*big.Int, *big.Int) (*big.Int, error), real func(Value, Value) (Value, error)) (Value, error) {
+	if _, ok := other.(Real); ok {
+		r, _ := b.Double()
+		return real(r, other)
+	}
+	x, err := toBig(other)
+	if err != nil {
+		return nil, err
+	}
+	r, err := do(b.value, x)
+	if err != nil {
+		return nil, err
+	}
+	return BigValue(r), nil
+}
+
+func (b Big) compare(other Value, real func(Value, Value) (Value, error), cmp func(int) bool) (Value, error) {
+	if _, ok := other.(Real); ok {
+		r, _ := b.Double()
+		return real(r, other)
+	}
+	x, err := toBig(other)
+	if err != nil {
+		return nil, err
+	}
+	return BoolValue(cmp(b.value.Cmp(x))), nil
+}
+
+func (b Big) shift(other Value, do func(*big.Int, uint) *big.Int) (Value, error) {
+	x, err := toBig(other)
+	if err != nil {
+		return nil, err
+	}
+	if x.Sign() < 0 {
+		return nil, fmt.Errorf("negative shift count")
+	}
+	if !x.IsUint64() || x.Uint64() > 1<<20 {
+		return nil, fmt.Errorf("shift count too large")
+	}
+	return BigValue(do(b.value, uint(x.Uint64()))), nil
+}
+
+func (b Big) bitwise(other Value, do func(*big.Int, *big.Int) *big.Int) (Value, error) {
+	x, err := toBig(other)
+	if err != nil {
+		return nil, err
+	}
+	return BigValue(do(b.value, x)), nil
+}
+
+func floorDivMod(x, y *big.Int) (*big.Int, *big.Int) {
+	q, r := new(big.Int).QuoRem(x, y, new(big.Int))
+	if r.Sign() != 0 && r.Sign() != y.Sign() {
+		q.Sub(q, big.NewInt(1))
+		r.Add(r, y)
+	}
+	return q, r
+}
+
+func toBig(v Value) (*big.Int, error) {
+	switch x := v.(type) {
+	case Integer:
+		return big.NewInt(x.value), nil
+	case Big:
+		return x.value, nil
+	case Boolean:
+		if x.value {
+			return big.NewInt(1), nil
+		}
+		return new(big.Int), nil
+	default:
+		return nil, incompatibleType()
+	}
+}
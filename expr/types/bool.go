@@ -71,7 +71,11 @@ func (b Boolean) Pow(other Value) (Value, error) {
 }
 
 func (b Boolean) And(other Value) (Value, error) {
-	x, ok := other.(Boolean)
+	v, err := other.Bool()
+	if err != nil {
+		return nil, err
+	}
+	x, ok := v.(Boolean)
 	if !ok {
 		return nil, incompatibleType()
 	}
@@ -80,7 +84,11 @@ func (b Boolean) And(other Value) (Value, error) {
 }
 
 func (b Boolean) Or(other Value) (Value, error) {
-	x, ok := other.(Boolean)
+	v, err := other.Bool()
+	if err != nil {
+		return nil, err
+	}
+	x, ok := v.(Boolean)
 	if !ok {
 		return nil, incompatibleType()
 	}
@@ -2,6 +2,8 @@ package types
 
 import (
 	"fmt"
+	"math"
+	"math/big"
 	"strconv"
 )
 
@@ -36,6 +38,9 @@ func (i Integer) Not() (Value, error) {
 }
 
 func (i Integer) Rev() (Value, error) {
+	if i.value == math.MinInt64 {
+		return i.big().Rev()
+	}
 	i.value = -i.value
 	return i, nil
 }
@@ -45,7 +50,13 @@ func (i Integer) Add(other Value) (Value, error) {
 	default:
 		return nil, incompatibleType()
 	case Integer:
-		i.value += x.value
+		r := i.value + x.value
+		if (x.value > 0 && r < i.value) || (x.value < 0 && r > i.value) {
+			return i.big().Add(other)
+		}
+		i.value = r
+	case Big:
+		return i.big().Add(other)
 	case Real:
 		r, _ := i.Double()
 		return r.Add(other)
@@ -58,7 +69,13 @@ func (i Integer) Sub(other Value) (Value, error) {
 	default:
 		return nil, incompatibleType()
 	case Integer:
-		i.value -= x.value
+		r := i.value - x.value
+		if (x.value > 0 && r > i.value) || (x.value < 0 && r < i.value) {
+			return i.big().Sub(other)
+		}
+		i.value = r
+	case Big:
+		return i.big().Sub(other)
 	case Real:
 		r, _ := i.Double()
 		return r.Sub(other)
@@ -74,7 +91,16 @@ func (i Integer) Div(other Value) (Value, error) {
 		if x.value == 0 {
 			return nil, ErrZero
 		}
-		i.value /= x.value
+		if i.value == math.MinInt64 && x.value == -1 {
+			return i.big().Div(other)
+		}
+		q := i.value / x.value
+		if i.value%x.value != 0 && (i.value < 0) != (x.value < 0) {
+			q--
+		}
+		i.value = q
+	case Big:
+		return i.big().Div(other)
 	case Real:
 		r, _ := i.Double()
 		return r.Div(other)
@@ -90,7 +116,17 @@ func (i Integer) Mod(other Value) (Value, error) {
 		if x.value == 0 {
 			return nil, ErrZero
 		}
-		i.value %= x.value
+		if x.value == -1 {
+			i.value = 0
+			break
+		}
+		r := i.value % x.value
+		if r != 0 && (r < 0) != (x.value < 0) {
+			r += x.value
+		}
+		i.value = r
+	case Big:
+		return i.big().Mod(other)
 	case Real:
 		r, _ := i.Double()
 		return r.Mod(other)
@@ -103,7 +139,17 @@ func (i Integer) Mul(other Value) (Value, error) {
 	default:
 		return nil, incompatibleType()
 	case Integer:
-		i.value *= x.value
+		if i.value == 0 || x.value == 0 {
+			i.value = 0
+			break
+		}
+		r := i.value * x.value
+		if r/x.value != i.value || (i.value == -1 && x.value == math.MinInt64) || (x.value == -1 && i.value == math.MinInt64) {
+			return i.big().Mul(other)
+		}
+		i.value = r
+	case Big:
+		return i.big().Mul(other)
 	case Real:
 		r, _ := i.Double()
 		return r.Mul(other)
@@ -112,21 +158,15 @@ func (i Integer) Mul(other Value) (Value, error) {
 }
 
 func (i Integer) Pow(other Value) (Value, error) {
-	switch x := other.(type) {
+	switch other.(type) {
 	default:
 		return nil, incompatibleType()
-	case Integer:
-		var (
-			r1, _ = i.Double()
-			r2, _ = x.Double()
-			r, _  = r1.Pow(r2)
-		)
-		return r.Int()
+	case Integer, Big:
+		return i.big().Pow(other)
 	case Real:
 		r, _ := i.Double()
 		return r.Pow(other)
 	}
-	return i, nil
 }
 
 func (i Integer) And(other Value) (Value, error) {
@@ -152,11 +192,14 @@ func (i Integer) Or(other Value) (Value, error) {
 }
 
 func (i Integer) Eq(other Value) (Value, error) {
-	x, ok := other.(Integer)
-	if !ok {
+	switch x := other.(type) {
+	case Integer:
+		return BoolValue(i.value == x.value), nil
+	case Big:
+		return i.big().Eq(other)
+	default:
 		return nil, incompatibleType()
 	}
-	return BoolValue(i.value == x.value), nil
 }
 
 func (i Integer) Ne(other Value) (Value, error) {
@@ -168,11 +211,14 @@ func (i Integer) Ne(other Value) (Value, error) {
 }
 
 func (i Integer) Lt(other Value) (Value, error) {
-	x, ok := other.(Integer)
-	if !ok {
+	switch x := other.(type) {
+	case Integer:
+		return BoolValue(i.value < x.value), nil
+	case Big:
+		return i.big().Lt(other)
+	default:
 		return nil, incompatibleType()
 	}
-	return BoolValue(i.value < x.value), nil
 }
 
 func (i Integer) Le(other Value) (Value, error) {
@@ -190,11 +236,14 @@ func (i Integer) Le(other Value) (Value, error) {
 }
 
 func (i Integer) Gt(other Value) (Value, error) {
-	x, ok := other.(Integer)
-	if !ok {
+	switch x := other.(type) {
+	case Integer:
+		return BoolValue(i.value > x.value), nil
+	case Big:
+		return i.big().Gt(other)
+	default:
 		return nil, incompatibleType()
 	}
-	return BoolValue(i.value > x.value), nil
 }
 
 func (i Integer) Ge(other Value) (Value, error) {
@@ -217,57 +266,85 @@ func (i Integer) Bnot() (Value, error) {
 }
 
 func (i Integer) Lshift(other Value) (Value, error) {
-	x, ok := other.(Integer)
-	if !ok {
+	switch x := other.(type) {
+	case Integer:
+		if x.value < 0 {
+			return nil, fmt.Errorf("negative shift count")
+		}
+		if x.value < 63 {
+			r := i.value << x.value
+			if r>>x.value == i.value {
+				return Integer{value: r}, nil
+			}
+		}
+		return i.big().Lshift(other)
+	case Big:
+		return i.big().Lshift(other)
+	default:
 		return nil, incompatibleType()
 	}
-	if x.value < 0 {
-		return nil, fmt.Errorf("negative shift count")
-	}
-	return Integer{
-		value: i.value << x.value,
-	}, nil
 }
 
 func (i Integer) Rshift(other Value) (Value, error) {
-	x, ok := other.(Integer)
-	if !ok {
+	switch x := other.(type) {
+	case Integer:
+		if x.value < 0 {
+			return nil, fmt.Errorf("negative shift count")
+		}
+		if x.value > 63 {
+			x.value = 63
+		}
+		return Integer{
+			value: i.value >> x.value,
+		}, nil
+	case Big:
+		return i.big().Rshift(other)
+	default:
 		return nil, incompatibleType()
 	}
-	if x.value < 0 {
-		return nil, fmt.Errorf("negative shift count")
-	}
-	return Integer{
-		value: i.value >> x.value,
-	}, nil
 }
 
 func (i Integer) Band(other Value) (Value, error) {
-	x, ok := other.(Integer)
-	if !ok {
+	switch x := other.(type) {
+	case Integer:
+		return Integer{
+			value: i.value & x.value,
+		}, nil
+	case Big:
+		return i.big().Band(other)
+	default:
 		return nil, incompatibleType()
 	}
-	return Integer{
-		value: i.value & x.value,
-	}, nil
 }
 
 func (i Integer) Bor(other Value) (Value, error) {
-	x, ok := other.(Integer)
-	if !ok {
+	switch x := other.(type) {
+	case Integer:
+		return Integer{
+			value: i.value | x.value,
+		}, nil
+	case Big:
+		return i.big().Bor(other)
+	default:
 		return nil, incompatibleType()
 	}
-	return Integer{
-		value: i.value | x.value,
-	}, nil
 }
 
 func (i Integer) Bxor(other Value) (Value, error) {
-	x, ok := other.(Integer)
-	if !ok {
+	switch x := other.(type) {
+	case Integer:
+		return Integer{
+			value: i.value ^ x.value,
+		}, nil
+	case Big:
+		return i.big().Bxor(other)
+	default:
 		return nil, incompatibleType()
 	}
-	return Integer{
-		value: i.value ^ x.value,
-	}, nil
+}
+
+func (i Integer) big() Big {
+	return Big{
+		value: big.NewInt(i.value),
+	}
 }
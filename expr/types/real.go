@@ -1,8 +1,11 @@
 package types
 
 import (
+	"fmt"
 	"math"
-	"strconv"
+	"math/big"
+
+	"github.com/midbel/gotcl/env"
 )
 
 type Real struct {
@@ -20,7 +23,14 @@ func (r Real) Bool() (Value, error) {
 }
 
 func (r Real) Int() (Value, error) {
-	return IntValue(int64(r.value)), nil
+	if math.IsInf(r.value, 0) || math.IsNaN(r.value) {
+		return nil, fmt.Errorf("%s: %w to integer", r, ErrCast)
+	}
+	if r.value >= math.MinInt64 && r.value < math.MaxInt64 {
+		return IntValue(int64(r.value)), nil
+	}
+	b, _ := big.NewFloat(r.value).Int(nil)
+	return BigValue(b), nil
 }
 
 func (r Real) Double() (Value, error) {
@@ -28,7 +38,7 @@ func (r Real) Double() (Value, error) {
 }
 
 func (r Real) String() string {
-	return strconv.FormatFloat(r.value, 'g', -1, 64)
+	return env.FormatFloat(r.value)
 }
 
 func (r Real) Not() (Value, error) {
@@ -0,0 +1,205 @@
+package types
+
+import (
+	"fmt"
+
+	"github.com/midbel/gotcl/env"
+)
+
+type String struct {
+	value string
+}
+
+func StrValue(s string) Value {
+	return String{
+		value: s,
+	}
+}
+
+func (s String) Bool() (Value, error) {
+	b, err := env.ParseBool(s.value)
+	if err != nil {
+		return nil, err
+	}
+	return BoolValue(b), nil
+}
+
+func (s String) Int() (Value, error) {
+	n, err := s.number()
+	if err != nil {
+		return nil, err
+	}
+	return n.Int()
+}
+
+func (s String) Double() (Value, error) {
+	n, err := s.number()
+	if err != nil {
+		return nil, err
+	}
+	return n.Double()
+}
+
+func (s String) String() string {
+	return s.value
+}
+
+func (s String) Not() (Value, error) {
+	b, err := s.Bool()
+	if err != nil {
+		return nil, err
+	}
+	return b.Not()
+}
+
+func (s String) Rev() (Value, error) {
+	n, err := s.number()
+	if err != nil {
+		return nil, err
+	}
+	return n.Rev()
+}
+
+func (s String) Add(other Value) (Value, error) {
+	n, err := s.number()
+	if err != nil {
+		return nil, err
+	}
+	return n.Add(other)
+}
+
+func (s String) Sub(other Value) (Value, error) {
+	n, err := s.number()
+	if err != nil {
+		return nil, err
+	}
+	return n.Sub(other)
+}
+
+func (s String) Div(other Value) (Value, error) {
+	n, err := s.number()
+	if err != nil {
+		return nil, err
+	}
+	return n.Div(other)
+}
+
+func (s String) Mod(other Value) (Value, error) {
+	n, err := s.number()
+	if err != nil {
+		return nil, err
+	}
+	return n.Mod(other)
+}
+
+func (s String) Mul(other Value) (Value, error) {
+	n, err := s.number()
+	if err != nil {
+		return nil, err
+	}
+	return n.Mul(other)
+}
+
+func (s String) Pow(other Value) (Value, error) {
+	n, err := s.number()
+	if err != nil {
+		return nil, err
+	}
+	return n.Pow(other)
+}
+
+func (s String) And(other Value) (Value, error) {
+	b, err := s.Bool()
+	if err != nil {
+		return nil, err
+	}
+	return b.And(other)
+}
+
+func (s String) Or(other Value) (Value, error) {
+	b, err := s.Bool()
+	if err != nil {
+		return nil, err
+	}
+	return b.Or(other)
+}
+
+func (s String) Eq(other Value) (Value, error) {
+	return BoolValue(s.value == other.String()), nil
+}
+
+func (s String) Ne(other Value) (Value, error) {
+	return BoolValue(s.value != other.String()), nil
+}
+
+func (s String) Lt(other Value) (Value, error) {
+	return BoolValue(s.value < other.String()), nil
+}
+
+func (s String) Le(other Value) (Value, error) {
+	return BoolValue(s.value <= other.String()), nil
+}
+
+func (s String) Gt(other Value) (Value, error) {
+	return BoolValue(s.value > other.String()), nil
+}
+
+func (s String) Ge(other Value) (Value, error) {
+	return BoolValue(s.value >= other.String()), nil
+}
+
+func (s String) Bnot() (Value, error) {
+	n, err := s.number()
+	if err != nil {
+		return nil, err
+	}
+	return n.Bnot()
+}
+
+func (s String) Lshift(other Value) (Value, error) {
+	n, err := s.number()
+	if err != nil {
+		return nil, err
+	}
+	return n.Lshift(other)
+}
+
+func (s String) Rshift(other Value) (Value, error) {
+	n, err := s.number()
+	if err != nil {
+		return nil, err
+	}
+	return n.Rshift(other)
+}
+
+func (s String) Band(other Value) (Value, error) {
+	n, err := s.number()
+	if err != nil {
+		return nil, err
+	}
+	return n.Band(other)
+}
+
+func (s String) Bor(other Value) (Value, error) {
+	n, err := s.number()
+	if err != nil {
+		return nil, err
+	}
+	return n.Bor(other)
+}
+
+func (s String) Bxor(other Value) (Value, error) {
+	n, err := s.number()
+	if err != nil {
+		return nil, err
+	}
+	return n.Bxor(other)
+}
+
+func (s String) number() (Value, error) {
+	n, err := env.ParseNumber(s.value)
+	if err != nil {
+		return nil, fmt.Errorf("can't use non-numeric string %q as operand", s.value)
+	}
+	return AsValue(n)
+}
@@ -3,6 +3,7 @@ package types
 import (
 	"errors"
 	"fmt"
+	"math/big"
 
 	"github.com/midbel/gotcl/env"
 )
@@ -47,12 +48,21 @@ func AsFloat(v Value) (float64, error) {
 		return float64(x.value), nil
 	case Real:
 		return x.value, nil
+	case Big:
+		r, _ := x.Double()
+		return AsFloat(r)
 	case Boolean:
 		var res float64
 		if x.value {
 			res += 1
 		}
 		return res, nil
+	case String:
+		n, err := x.Double()
+		if err != nil {
+			return 0, err
+		}
+		return AsFloat(n)
 	default:
 		return 0, incompatibleType()
 	}
@@ -64,27 +74,113 @@ func AsValue(str env.Value) (Value, error) {
 	case env.Boolean:
 		val = BoolValue(env.ToBool(e))
 	case env.Number:
-		n, err := env.ToFloat(e)
-		if err != nil {
-			return nil, err
+		if !e.IsInt() {
+			n, err := env.ToFloat(e)
+			if err != nil {
+				return nil, err
+			}
+			return RealValue(n), nil
 		}
-		val = RealValue(n)
-	case env.String:
-		n, err := str.ToNumber()
-		if err != nil {
-			return nil, err
+		if n, ok := e.Int64(); ok {
+			return IntValue(n), nil
 		}
-		return AsValue(n)
+		val = BigValue(e.Big())
+	case nil:
+		return nil, fmt.Errorf("value can not be converted to Value")
 	default:
-		return nil, fmt.Errorf("%s can not be converted to Value", str)
+		val = StrValue(str.String())
 	}
 	return val, nil
 }
 
+func Coerce(left, right Value) (Value, Value) {
+	_, ls := left.(String)
+	_, rs := right.(String)
+	if ls || rs {
+		n1, err1 := asNumber(left)
+		n2, err2 := asNumber(right)
+		if err1 != nil || err2 != nil {
+			return StrValue(left.String()), StrValue(right.String())
+		}
+		left, right = n1, n2
+	}
+	switch {
+	case isReal(left) || isReal(right):
+		left, _ = left.Double()
+		right, _ = right.Double()
+	case isInt(left) || isInt(right):
+		left, _ = left.Int()
+		right, _ = right.Int()
+	default:
+	}
+	return left, right
+}
+
+func isReal(v Value) bool {
+	_, ok := v.(Real)
+	return ok
+}
+
+func isInt(v Value) bool {
+	_, ok := v.(Integer)
+	return ok
+}
+
+func asNumber(v Value) (Value, error) {
+	if s, ok := v.(String); ok {
+		return s.number()
+	}
+	return v, nil
+}
+
+func AsEnv(v Value) env.Value {
+	switch x := v.(type) {
+	case Boolean:
+		return env.Bool(x.value)
+	case Integer:
+		return env.Int(x.value)
+	case Big:
+		return env.BigInt(x.value)
+	case Real:
+		return env.Float(x.value)
+	default:
+		return env.Str(v.String())
+	}
+}
+
+// AsNumber gives the numeric value of v for the unary plus operator.
+func AsNumber(v Value) (Value, error) {
+	switch x := v.(type) {
+	case Integer, Big, Real:
+		return v, nil
+	case String:
+		return x.number()
+	default:
+		return nil, unsupportedOp("+", "boolean")
+	}
+}
+
+func AsBig(v Value) (*big.Int, error) {
+	n, err := v.Int()
+	if err != nil {
+		return nil, err
+	}
+	switch x := n.(type) {
+	case Big:
+		return x.value, nil
+	case Integer:
+		return big.NewInt(x.value), nil
+	default:
+		return nil, incompatibleType()
+	}
+}
+
 func AsInt(v Value) (int64, error) {
 	switch x := v.(type) {
 	case Integer:
 		return x.value, nil
+	case Big:
+		return x.value.Int64(), nil
 	case Real:
 		return int64(x.value), nil
 	case Boolean:
@@ -93,6 +189,12 @@ func AsInt(v Value) (int64, error) {
 			res++
 		}
 		return res, nil
+	case String:
+		n, err := x.Int()
+		if err != nil {
+			return 0, err
+		}
+		return AsInt(n)
 	default:
 		return 0, incompatibleType()
 	}
@@ -108,8 +210,16 @@ func AsBool(v Value) (bool, error) {
 		return x.value != 0, nil
 	case Real:
 		return x.value != 0, nil
+	case Big:
+		return x.value.Sign() != 0, nil
 	case Boolean:
 		return x.value, nil
+	case String:
+		b, err := x.Bool()
+		if err != nil {
+			return false, err
+		}
+		return AsBool(b)
 	default:
 		return false, incompatibleType()
 	}
@@ -131,5 +241,5 @@ func incompatibleType() error {
 }
 
 func unsupportedCast(src, dst string) error {
-	return fmt.Errorf("%s: %w to %s", ErrCast)
+	return fmt.Errorf("%s: %w to %s", src, ErrCast, dst)
 }
@@ -0,0 +1,584 @@
+package interp
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/midbel/gotcl/env"
+	"github.com/midbel/gotcl/stdlib/encoding"
+)
+
+const (
+	bufferNone = "none"
+	bufferLine = "line"
+	bufferFull = "full"
+)
+
+const (
+	transAuto   = "auto"
+	transBinary = "binary"
+	transLf     = "lf"
+	transCr     = "cr"
+	transCrlf   = "crlf"
+)
+
+const (
+	optBlocking    = "-blocking"
+	optBuffering   = "-buffering"
+	optBufferSize  = "-buffersize"
+	optEncoding    = "-encoding"
+	optEofChar     = "-eofchar"
+	optTranslation = "-translation"
+	optPeerName    = "-peername"
+	optSockName    = "-sockname"
+)
+
+const (
+	defaultBufferSize = 4096
+	maxBufferSize     = 1 << 20
+)
+
+type Channel struct {
+	rw       io.ReadWriteCloser
+	readable bool
+	writable bool
+
+	raw     []byte
+	text    string
+	out     []byte
+	cr      bool
+	eof     bool
+	blocked bool
+
+	buffering string
+	bufsize   int
+	blocking  bool
+	inTrans   string
+	outTrans  string
+	autoTrans string
+	inEof     string
+	outEof    string
+	encoding  string
+	codec     encoding.Encoding
+
+	chunks chan chunk
+	notify chan struct{}
+	done   chan struct{}
+}
+
+type chunk struct {
+	data []byte
+	err  error
+}
+
+func newChannel(rw io.ReadWriteCloser, readable, writable bool) *Channel {
+	c := Channel{
+		rw:        rw,
+		readable:  readable,
+		writable:  writable,
+		buffering: bufferFull,
+		bufsize:   defaultBufferSize,
+		blocking:  true,
+		inTrans:   transAuto,
+		autoTrans: transLf,
+	}
+	if runtime.GOOS == "windows" {
+		c.autoTrans = transCrlf
+	}
+	switch rw.(type) {
+	case net.Conn, *asyncConn:
+		c.autoTrans = transCrlf
+	default:
+	}
+	c.outTrans = c.autoTrans
+	c.setEncoding(encoding.System())
+	return &c
+}
+
+func (c *Channel) Read(p []byte) (int, error) {
+	for c.text == "" {
+		if c.eof {
+			return 0, io.EOF
+		}
+		if err := c.fill(true); err != nil {
+			return 0, err
+		}
+	}
+	n := copy(p, c.text)
+	c.text = c.text[n:]
+	return n, nil
+}
+
+func (c *Channel) ReadLine() (string, error) {
+	for {
+		if x := strings.IndexByte(c.text, '\n'); x >= 0 {
+			line := c.text[:x]
+			c.text = c.text[x+1:]
+			return line, nil
+		}
+		if c.eof {
+			if c.text == "" {
+				return "", io.EOF
+			}
+			line := c.text
+			c.text = ""
+			return line, nil
+		}
+		if err := c.fill(c.blocking); err != nil {
+			return "", err
+		}
+		if c.blocked {
+			return "", io.EOF
+		}
+	}
+}
+
+func (c *Channel) ReadChars(n int) (string, error) {
+	for !c.eof && (n <= 0 || utf8.RuneCountInString(c.text) < n) {
+		if err := c.fill(c.blocking); err != nil {
+			return "", err
+		}
+		if c.blocked {
+			break
+		}
+	}
+	str := c.text
+	if n > 0 {
+		var x int
+		for ; n > 0 && x < len(str); n-- {
+			_, z := utf8.DecodeRuneInString(str[x:])
+			x += z
+		}
+		str = str[:x]
+	}
+	c.text = c.text[len(str):]
+	return str, nil
+}
+
+// poll blocks until the reader goroutine of the channel holds data that the
+// interpreter has not taken yet. It never touches the buffers of the channel.
+func (c *Channel) poll(stop <-chan struct{}) {
+	select {
+	case <-c.notify:
+	case <-stop:
+	}
+}
+
+func (c *Channel) buffered() bool {
+	return c.text != "" || c.eof
+}
+
+// pump starts the goroutine that reads the underlying stream. Once started,
+// the channel only gets data from it.
+func (c *Channel) pump() {
+	if c.chunks != nil {
+		return
+	}
+	c.chunks = make(chan chunk)
+	c.notify = make(chan struct{})
+	c.done = make(chan struct{})
+	go readChunks(c.rw, c.bufsize, c.chunks, c.notify, c.done)
+}
+
+func readChunks(r io.Reader, size int, chunks chan<- chunk, notify chan<- struct{}, done <-chan struct{}) {
+	for {
+		var (
+			buf    = make([]byte, size)
+			n, err = r.Read(buf)
+			ck     = chunk{data: buf[:n], err: err}
+		)
+		for sent := false; !sent; {
+			select {
+			case chunks <- ck:
+				sent = true
+			case notify <- struct{}{}:
+			case <-done:
+				return
+			}
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+func (c *Channel) Write(p []byte) (int, error) {
+	if !c.writable {
+		return 0, fmt.Errorf("channel wasn't opened for writing")
+	}
+	str := string(p)
+	switch c.outTrans {
+	case transCrlf:
+		str = strings.ReplaceAll(str, "\n", "\r\n")
+	case transCr:
+		str = strings.ReplaceAll(str, "\n", "\r")
+	default:
+	}
+	c.out = append(c.out, c.codec.Encode(str)...)
+
+	var err error
+	switch c.buffering {
+	case bufferNone:
+		err = c.Flush()
+	case bufferLine:
+		if strings.IndexByte(string(p), '\n') >= 0 {
+			err = c.Flush()
+		}
+	default:
+		if len(c.out) >= c.bufsize {
+			err = c.Flush()
+		}
+	}
+	return len(p), err
+}
+
+func (c *Channel) Flush() error {
+	if len(c.out) == 0 {
+		return nil
+	}
+	_, err := c.rw.Write(c.out)
+	c.out = c.out[:0]
+	return err
+}
+
+func (c *Channel) Close() error {
+	if c.done != nil {
+		close(c.done)
+	}
+	err := c.Flush()
+	if c.writable && c.outEof != "" {
+		c.rw.Write([]byte(c.outEof))
+	}
+	if e := c.rw.Close(); e != nil {
+		err = e
+	}
+	return err
+}
+
+func (c *Channel) CloseRead() error {
+	if !c.readable {
+		return fmt.Errorf("channel wasn't opened for reading")
+	}
+	h, ok := c.rw.(interface{ CloseRead() error })
+	if !ok {
+		return fmt.Errorf("channel can not be half closed")
+	}
+	if c.done != nil {
+		close(c.done)
+		c.done = nil
+	}
+	c.readable = false
+	c.raw, c.text, c.cr, c.eof = nil, "", false, false
+	return h.CloseRead()
+}
+
+func (c *Channel) CloseWrite() error {
+	if !c.writable {
+		return fmt.Errorf("channel wasn't opened for writing")
+	}
+	h, ok := c.rw.(interface{ CloseWrite() error })
+	if !ok {
+		return fmt.Errorf("channel can not be half closed")
+	}
+	err := c.Flush()
+	if c.outEof != "" {
+		c.rw.Write([]byte(c.outEof))
+	}
+	c.writable = false
+	if e := h.CloseWrite(); e != nil {
+		err = e
+	}
+	return err
+}
+
+func (c *Channel) Seek(offset int64, whence int) (int64, error) {
+	s, ok := c.rw.(io.Seeker)
+	if !ok {
+		return 0, fmt.Errorf("channel is not seekable")
+	}
+	if err := c.Flush(); err != nil {
+		return 0, err
+	}
+	if whence == io.SeekCurrent {
+		offset -= int64(len(c.raw) + len(c.codec.Encode(c.text)))
+		if c.cr {
+			offset--
+		}
+	}
+	c.raw, c.text, c.cr, c.eof = nil, "", false, false
+	return s.Seek(offset, whence)
+}
+
+func (c *Channel) Eof() bool {
+	return c.eof && c.text == ""
+}
+
+func (c *Channel) Blocked() bool {
+	return c.blocked
+}
+
+func (c *Channel) Names() []string {
+	list := []string{
+		optBlocking,
+		optBuffering,
+		optBufferSize,
+		optEncoding,
+		optEofChar,
+		optTranslation,
+	}
+	switch c.rw.(type) {
+	case *server:
+		list = append(list, optSockName)
+	case net.Conn, *asyncConn:
+		list = append(list, optPeerName, optSockName)
+	default:
+	}
+	return list
+}
+
+func (c *Channel) Get(option string) ([]string, error) {
+	switch option {
+	case optBlocking:
+		if c.blocking {
+			return []string{"1"}, nil
+		}
+		return []string{"0"}, nil
+	case optBuffering:
+		return []string{c.buffering}, nil
+	case optBufferSize:
+		return []string{strconv.Itoa(c.bufsize)}, nil
+	case optEncoding:
+		return []string{c.encoding}, nil
+	case optEofChar:
+		return c.pair(c.inEof, c.outEof), nil
+	case optTranslation:
+		return c.pair(c.inTrans, c.outTrans), nil
+	case optPeerName, optSockName:
+		return c.address(option)
+	default:
+		return nil, c.badOption(option)
+	}
+}
+
+func (c *Channel) Set(option string, values []string) error {
+	if option != optEofChar && option != optTranslation && len(values) != 1 {
+		return fmt.Errorf("bad value for %s: expected a single value", option)
+	}
+	switch option {
+	case optBlocking:
+		b, err := env.ParseBool(values[0])
+		if err != nil {
+			return err
+		}
+		c.blocking = b
+	case optBuffering:
+		switch v := values[0]; v {
+		case bufferNone, bufferLine, bufferFull:
+			c.buffering = v
+		default:
+			return fmt.Errorf("bad value for -buffering: must be one of full, line, or none")
+		}
+	case optBufferSize:
+		n, err := strconv.Atoi(values[0])
+		if err != nil {
+			return fmt.Errorf("expected integer but got %q", values[0])
+		}
+		if n < 1 {
+			n = 1
+		} else if n > maxBufferSize {
+			n = maxBufferSize
+		}
+		c.bufsize = n
+	case optEncoding:
+		return c.setEncoding(values[0])
+	case optEofChar:
+		in, out, err := c.split(values)
+		if err != nil {
+			return err
+		}
+		for _, e := range []string{in, out} {
+			if len(e) > 1 || (e != "" && e[0] >= utf8.RuneSelf) {
+				return fmt.Errorf("bad value for -eofchar: must be non-NUL ASCII character")
+			}
+		}
+		c.inEof, c.outEof = in, out
+	case optTranslation:
+		in, out, err := c.split(values)
+		if err != nil {
+			return err
+		}
+		for _, t := range []string{in, out} {
+			switch t {
+			case transAuto, transBinary, transLf, transCr, transCrlf:
+			default:
+				return fmt.Errorf("bad value for -translation: must be one of auto, binary, cr, lf, or crlf")
+			}
+		}
+		if in == transBinary || out == transBinary {
+			c.setEncoding("binary")
+			c.inEof, c.outEof = "", ""
+		}
+		if in == transBinary {
+			in = transLf
+		}
+		if out == transAuto {
+			out = c.autoTrans
+		} else if out == transBinary {
+			out = transLf
+		}
+		c.inTrans, c.outTrans = in, out
+	case optPeerName, optSockName:
+		return fmt.Errorf("option %s is read-only", option)
+	default:
+		return c.badOption(option)
+	}
+	return nil
+}
+
+func (c *Channel) fill(block bool) error {
+	if c.eof {
+		return nil
+	}
+	if !c.readable {
+		return fmt.Errorf("channel wasn't opened for reading")
+	}
+	if !block {
+		c.pump()
+	}
+	var ck chunk
+	if c.chunks != nil {
+		var timeout <-chan time.Time
+		if !block {
+			timeout = time.After(time.Millisecond)
+		}
+		select {
+		case ck = <-c.chunks:
+		case <-timeout:
+			c.blocked = true
+			return nil
+		}
+	} else {
+		buf := make([]byte, c.bufsize)
+		n, err := c.rw.Read(buf)
+		ck = chunk{data: buf[:n], err: err}
+	}
+	c.blocked = false
+	c.raw = append(c.raw, ck.data...)
+	if ck.err != nil {
+		if !errors.Is(ck.err, io.EOF) {
+			return ck.err
+		}
+		c.eof = true
+	}
+	c.decode()
+	return nil
+}
+
+func (c *Channel) decode() {
+	if c.inEof != "" {
+		if x := strings.IndexByte(string(c.raw), c.inEof[0]); x >= 0 {
+			c.raw = c.raw[:x]
+			c.eof = true
+		}
+	}
+	str, n := c.codec.Decode(c.raw, c.eof)
+	c.raw = c.raw[n:]
+	if c.cr {
+		str = "\r" + str
+		c.cr = false
+	}
+	switch c.inTrans {
+	case transAuto, transCrlf:
+		if !c.eof && strings.HasSuffix(str, "\r") {
+			str = str[:len(str)-1]
+			c.cr = true
+		}
+		str = strings.ReplaceAll(str, "\r\n", "\n")
+		if c.inTrans == transAuto {
+			str = strings.ReplaceAll(str, "\r", "\n")
+		}
+	case transCr:
+		str = strings.ReplaceAll(str, "\r", "\n")
+	default:
+	}
+	c.text += str
+}
+
+func (c *Channel) setEncoding(name string) error {
+	codec, err := encoding.Lookup(name)
+	if err != nil {
+		return fmt.Errorf("unknown encoding %q", name)
+	}
+	c.encoding, c.codec = strings.ToLower(name), codec
+	return nil
+}
+
+func (c *Channel) pair(in, out string) []string {
+	switch {
+	case c.readable && c.writable:
+		return []string{in, out}
+	case c.readable:
+		return []string{in}
+	default:
+		return []string{out}
+	}
+}
+
+func (c *Channel) split(values []string) (string, string, error) {
+	switch len(values) {
+	case 0:
+		return "", "", nil
+	case 1:
+		return values[0], values[0], nil
+	case 2:
+		return values[0], values[1], nil
+	default:
+		return "", "", fmt.Errorf("bad value: expected a list of one or two elements")
+	}
+}
+
+func (c *Channel) address(option string) ([]string, error) {
+	var addr net.Addr
+	switch rw := c.rw.(type) {
+	case *server:
+		if option == optSockName {
+			addr = rw.listener.Addr()
+		}
+	case *asyncConn:
+		if err := rw.wait(); err != nil {
+			return nil, err
+		}
+		addr = rw.conn.RemoteAddr()
+		if option == optSockName {
+			addr = rw.conn.LocalAddr()
+		}
+	case net.Conn:
+		addr = rw.RemoteAddr()
+		if option == optSockName {
+			addr = rw.LocalAddr()
+		}
+	default:
+	}
+	if addr == nil {
+		return nil, c.badOption(option)
+	}
+	host, port, err := net.SplitHostPort(addr.String())
+	if err != nil {
+		return nil, err
+	}
+	return []string{host, host, port}, nil
+}
+
+func (c *Channel) badOption(option string) error {
+	names := c.Names()
+	last := names[len(names)-1]
+	names = names[:len(names)-1]
+	return fmt.Errorf("bad option %q: should be one of %s, or %s", option, strings.Join(names, ", "), last)
+}
@@ -0,0 +1,182 @@
+package interp
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/midbel/gotcl/env"
+	"github.com/midbel/gotcl/stdlib"
+	"github.com/midbel/slices"
+)
+
+var errKilled = errors.New("coroutine deleted")
+
+type transfer struct {
+	value env.Value
+	err   error
+	cmd   []env.Value
+	done  bool
+	kill  bool
+}
+
+type coroutine struct {
+	name    string
+	ns      *Namespace
+	frames  []*Frame
+	calls   []callFrame
+	running bool
+	done    bool
+	killed  bool
+	spread  bool
+
+	resume chan transfer
+	yield  chan transfer
+}
+
+func (_ *coroutine) Scoped() bool {
+	return false
+}
+
+func (c *coroutine) GetName() string {
+	return c.name
+}
+
+func (_ *coroutine) IsSafe() bool {
+	return true
+}
+
+func (c *coroutine) Execute(i stdlib.Interpreter, args []env.Value) (env.Value, error) {
+	x, ok := i.(*Interpreter)
+	if !ok {
+		return nil, fmt.Errorf("interpreter can not resume coroutine")
+	}
+	value := env.EmptyStr()
+	switch {
+	case c.spread:
+		value = env.ListFrom(args...)
+	case len(args) > 1:
+		return nil, fmt.Errorf("wrong # args: should be \"%s ?arg?\"", c.name)
+	case len(args) == 1:
+		value = slices.Fst(args)
+	}
+	return c.switchTo(x, transfer{value: value})
+}
+
+func (c *coroutine) run(i *Interpreter, args []env.Value) {
+	msg := <-c.resume
+	if msg.kill {
+		c.yield <- transfer{err: errKilled, done: true}
+		return
+	}
+	res, err := i.execute(&Command{
+		Name: slices.Fst(args),
+		Args: slices.Rest(args),
+	})
+	if res == nil && err == nil {
+		res = env.EmptyStr()
+	}
+	c.yield <- transfer{value: res, err: err, done: true}
+}
+
+func (c *coroutine) switchTo(i *Interpreter, msg transfer) (env.Value, error) {
+	if c.running {
+		return nil, fmt.Errorf("coroutine %q is already running", c.name)
+	}
+	var (
+		frames = i.frames
+		calls  = i.calls
+		curr   = i.coro
+	)
+	i.frames, i.calls, i.coro = c.frames, c.calls, c
+	c.running = true
+
+	c.resume <- msg
+	res := <-c.yield
+
+	c.running = false
+	c.frames, c.calls = i.frames, i.calls
+	i.frames, i.calls, i.coro = frames, calls, curr
+
+	if res.done {
+		c.done = true
+		c.ns.Rename(c.name, "")
+		if errors.Is(res.err, errKilled) {
+			res.err = nil
+		}
+		return res.value, res.err
+	}
+	c.spread = res.cmd != nil
+	if c.spread {
+		return i.execute(&Command{
+			Name: slices.Fst(res.cmd),
+			Args: slices.Rest(res.cmd),
+		})
+	}
+	return res.value, nil
+}
+
+func (c *coroutine) suspend(msg transfer) (env.Value, error) {
+	c.yield <- msg
+	res := <-c.resume
+	if res.kill {
+		c.killed = true
+		return nil, errKilled
+	}
+	return res.value, nil
+}
+
+func (i *Interpreter) Coroutine(name string, args []env.Value) (env.Value, error) {
+	ns := i.currentNS()
+	if _, err := ns.LookupExec([]string{name}); err == nil {
+		return nil, fmt.Errorf("command %q already exists", name)
+	}
+	c := coroutine{
+		name:   name,
+		ns:     ns,
+		frames: []*Frame{i.rootFrame()},
+		resume: make(chan transfer),
+		yield:  make(chan transfer),
+	}
+	go c.run(i, args)
+	ns.RegisterExec([]string{name}, &c)
+	return c.switchTo(i, transfer{})
+}
+
+func (i *Interpreter) Yield(value env.Value) (env.Value, error) {
+	if i.coro == nil {
+		return nil, fmt.Errorf("yield can only be called in a coroutine")
+	}
+	return i.coro.suspend(transfer{value: value})
+}
+
+func (i *Interpreter) YieldTo(args []env.Value) (env.Value, error) {
+	if i.coro == nil {
+		return nil, fmt.Errorf("yieldto can only be called in a coroutine")
+	}
+	return i.coro.suspend(transfer{cmd: args})
+}
+
+func (i *Interpreter) CurrentCoroutine() string {
+	if i.coro == nil {
+		return ""
+	}
+	if i.coro.ns.Root() {
+		return "::" + i.coro.name
+	}
+	return i.coro.ns.FQN() + "::" + i.coro.name
+}
+
+// killedCoroutine reports whether the running coroutine has been deleted.
+// Once it is, every command it still tries to execute fails so that a catch
+// in its body can not keep it alive.
+func (i *Interpreter) killedCoroutine() bool {
+	return i.coro != nil && i.coro.killed
+}
+
+func (i *Interpreter) killCoroutine(exec stdlib.Executer) {
+	c, ok := exec.(*coroutine)
+	if !ok || c.done || c.running {
+		return
+	}
+	c.switchTo(i, transfer{kill: true})
+}
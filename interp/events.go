@@ -0,0 +1,398 @@
+package interp
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/midbel/gotcl/stdlib"
+)
+
+const (
+	eventTimer = "timer"
+	eventIdle  = "idle"
+)
+
+const (
+	eventReadable = "readable"
+	eventWritable = "writable"
+)
+
+type timer struct {
+	id     string
+	kind   string
+	when   time.Time
+	script string
+}
+
+type poller interface {
+	poll(<-chan struct{})
+}
+
+type watcher struct {
+	fd      string
+	kind    string
+	script  string
+	handler func() error
+	source  poller
+
+	armed bool
+	arm   chan struct{}
+	stop  chan struct{}
+}
+
+func (w *watcher) watch(ready chan<- *watcher) {
+	for {
+		select {
+		case <-w.arm:
+		case <-w.stop:
+			return
+		}
+		w.source.poll(w.stop)
+		select {
+		case ready <- w:
+		case <-w.stop:
+			return
+		}
+	}
+}
+
+func (w *watcher) always() bool {
+	return w.arm == nil
+}
+
+func (w *watcher) close() {
+	if w.stop != nil {
+		close(w.stop)
+	}
+}
+
+type eventLoop struct {
+	next     int
+	timers   []*timer
+	idles    []*timer
+	watchers map[string]*watcher
+	ready    chan *watcher
+	pending  []*watcher
+	vars     map[string]bool
+}
+
+func createLoop() *eventLoop {
+	return &eventLoop{
+		watchers: make(map[string]*watcher),
+		ready:    make(chan *watcher),
+		vars:     make(map[string]bool),
+	}
+}
+
+func (e *eventLoop) schedule(kind, script string, when time.Time) string {
+	e.next++
+	t := &timer{
+		id:     "after#" + strconv.Itoa(e.next),
+		kind:   kind,
+		when:   when,
+		script: script,
+	}
+	if kind == eventIdle {
+		e.idles = append(e.idles, t)
+		return t.id
+	}
+	x := sort.Search(len(e.timers), func(i int) bool {
+		return e.timers[i].when.After(when)
+	})
+	e.timers = append(e.timers[:x], append([]*timer{t}, e.timers[x:]...)...)
+	return t.id
+}
+
+func (e *eventLoop) cancel(id string) {
+	match := func(t *timer) bool {
+		return t.id == id || t.script == id
+	}
+	for j, t := range e.timers {
+		if match(t) {
+			e.timers = append(e.timers[:j], e.timers[j+1:]...)
+			return
+		}
+	}
+	for j, t := range e.idles {
+		if match(t) {
+			e.idles = append(e.idles[:j], e.idles[j+1:]...)
+			return
+		}
+	}
+}
+
+func (e *eventLoop) find(id string) (*timer, error) {
+	for _, t := range e.timers {
+		if t.id == id {
+			return t, nil
+		}
+	}
+	for _, t := range e.idles {
+		if t.id == id {
+			return t, nil
+		}
+	}
+	return nil, fmt.Errorf("event %q doesn't exist", id)
+}
+
+func (e *eventLoop) ids() []string {
+	var list []string
+	for _, t := range e.timers {
+		list = append(list, t.id)
+	}
+	for _, t := range e.idles {
+		list = append(list, t.id)
+	}
+	return list
+}
+
+func (e *eventLoop) expired(now time.Time) []*timer {
+	x := sort.Search(len(e.timers), func(i int) bool {
+		return e.timers[i].when.After(now)
+	})
+	list := e.timers[:x:x]
+	e.timers = e.timers[x:]
+	return list
+}
+
+func (e *eventLoop) readyWatchers() []*watcher {
+	list := e.pending
+	e.pending = nil
+	for {
+		select {
+		case w := <-e.ready:
+			list = append(list, w)
+			continue
+		default:
+		}
+		break
+	}
+	for _, w := range e.watchers {
+		if w.always() {
+			list = append(list, w)
+		}
+	}
+	var ready []*watcher
+	for _, w := range list {
+		w.armed = false
+		if k := e.watchers[w.fd+w.kind]; k == w {
+			ready = append(ready, w)
+		}
+	}
+	return ready
+}
+
+func (e *eventLoop) arm() {
+	for _, w := range e.watchers {
+		if w.always() || w.armed {
+			continue
+		}
+		if b, ok := w.source.(interface{ buffered() bool }); ok && b.buffered() {
+			e.pending = append(e.pending, w)
+			continue
+		}
+		w.armed = true
+		w.arm <- struct{}{}
+	}
+}
+
+func (e *eventLoop) wait() bool {
+	e.arm()
+	if len(e.idles) > 0 {
+		return true
+	}
+	for _, w := range e.watchers {
+		if w.always() {
+			return true
+		}
+	}
+	var timeout <-chan time.Time
+	if len(e.timers) > 0 {
+		t := time.NewTimer(time.Until(e.timers[0].when))
+		defer t.Stop()
+		timeout = t.C
+	}
+	if timeout == nil && len(e.watchers) == 0 {
+		return false
+	}
+	select {
+	case w := <-e.ready:
+		e.pending = append(e.pending, w)
+	case <-timeout:
+	}
+	return true
+}
+
+func (e *eventLoop) touch(name string) {
+	name = strings.TrimPrefix(name, "::")
+	if _, ok := e.vars[name]; ok {
+		e.vars[name] = true
+	}
+}
+
+func (i *Interpreter) After(ms int, script string) string {
+	when := time.Now().Add(time.Duration(ms) * time.Millisecond)
+	return i.events.schedule(eventTimer, script, when)
+}
+
+func (i *Interpreter) AfterIdle(script string) string {
+	return i.events.schedule(eventIdle, script, time.Now())
+}
+
+func (i *Interpreter) AfterCancel(id string) {
+	i.events.cancel(id)
+}
+
+func (i *Interpreter) AfterIds() []string {
+	return i.events.ids()
+}
+
+func (i *Interpreter) AfterInfo(id string) (string, string, error) {
+	t, err := i.events.find(id)
+	if err != nil {
+		return "", "", err
+	}
+	return t.script, t.kind, nil
+}
+
+func (i *Interpreter) Wait(name string) error {
+	name = strings.TrimPrefix(name, "::")
+	if _, ok := i.events.vars[name]; ok {
+		return fmt.Errorf("variable %q is already waited for", name)
+	}
+	i.events.vars[name] = false
+	defer delete(i.events.vars, name)
+
+	for !i.events.vars[name] {
+		ok, err := i.dispatch(false)
+		if err != nil {
+			return err
+		}
+		if ok {
+			continue
+		}
+		if !i.events.wait() {
+			return fmt.Errorf("can't wait for variable %q: would wait forever", name)
+		}
+	}
+	return nil
+}
+
+func (i *Interpreter) Update(idle bool) error {
+	if !idle {
+		i.events.arm()
+		if _, err := i.dispatch(false); err != nil {
+			return err
+		}
+	}
+	for len(i.events.idles) > 0 {
+		if _, err := i.dispatch(true); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (i *Interpreter) FileEvent(fd, kind, script string) error {
+	c, err := i.lookup(fd)
+	if err != nil {
+		return err
+	}
+	if kind != eventReadable && kind != eventWritable {
+		return fmt.Errorf("bad event name %q: must be readable or writable", kind)
+	}
+	key := fd + kind
+	if w, ok := i.events.watchers[key]; ok {
+		w.close()
+		delete(i.events.watchers, key)
+	}
+	if script == "" {
+		return nil
+	}
+	w := watcher{
+		fd:     fd,
+		kind:   kind,
+		script: script,
+	}
+	if kind == eventReadable {
+		c.pump()
+		w.source = c
+		w.arm = make(chan struct{}, 1)
+		w.stop = make(chan struct{})
+		go w.watch(i.events.ready)
+	}
+	i.events.watchers[key] = &w
+	return nil
+}
+
+func (i *Interpreter) GetFileEvent(fd, kind string) (string, error) {
+	if _, err := i.lookup(fd); err != nil {
+		return "", err
+	}
+	if kind != eventReadable && kind != eventWritable {
+		return "", fmt.Errorf("bad event name %q: must be readable or writable", kind)
+	}
+	w, ok := i.events.watchers[fd+kind]
+	if !ok {
+		return "", nil
+	}
+	return w.script, nil
+}
+
+func (i *Interpreter) Close(fd string) error {
+	for _, kind := range []string{eventReadable, eventWritable} {
+		if w, ok := i.events.watchers[fd+kind]; ok {
+			w.close()
+			delete(i.events.watchers, fd+kind)
+		}
+	}
+	return i.Fileset.Close(fd)
+}
+
+func (i *Interpreter) dispatch(idle bool) (bool, error) {
+	var fired bool
+	if !idle {
+		for _, t := range i.events.expired(time.Now()) {
+			fired = true
+			if err := i.executeEvent(t.script); errors.Is(err, stdlib.ErrExit) {
+				return fired, err
+			}
+		}
+		for _, w := range i.events.readyWatchers() {
+			fired = true
+			run := w.handler
+			if run == nil {
+				run = func() error {
+					return i.executeEvent(w.script)
+				}
+			}
+			if err := run(); err != nil {
+				if errors.Is(err, stdlib.ErrExit) {
+					return fired, err
+				}
+				i.FileEvent(w.fd, w.kind, "")
+			}
+		}
+	}
+	list := i.events.idles
+	i.events.idles = nil
+	for _, t := range list {
+		fired = true
+		if err := i.executeEvent(t.script); errors.Is(err, stdlib.ErrExit) {
+			return fired, err
+		}
+	}
+	return fired, nil
+}
+
+func (i *Interpreter) executeEvent(script string) error {
+	_, err := i.ExecuteLevel(strings.NewReader(script), 1, true)
+	if err != nil && !errors.Is(err, stdlib.ErrExit) {
+		i.Println(stderr, err.Error())
+	}
+	return err
+}
@@ -26,26 +26,40 @@ func DefaultSet() CommandSet {
 	set.registerCmd("unset", stdlib.RunUnset())
 	set.registerCmd("proc", stdlib.RunProc())
 	set.registerCmd("string", stdlib.MakeString())
+	set.registerCmd("regexp", stdlib.RunRegexp())
+	set.registerCmd("regsub", stdlib.RunRegsub())
+	set.registerCmd("format", stdlib.RunFormat())
+	set.registerCmd("scan", stdlib.RunScan())
 	set.registerCmd("interp", stdlib.MakeInterp())
 	set.registerCmd("eval", stdlib.RunEval())
 	set.registerCmd("eval", stdlib.RunSource())
+	set.registerCmd("apply", stdlib.RunApply())
+	set.registerCmd("coroutine", stdlib.RunCoroutine())
+	set.registerCmd("yield", stdlib.RunYield())
+	set.registerCmd("yieldto", stdlib.RunYieldTo())
 	set.registerCmd("upvar", stdlib.RunUpvar())
 	set.registerCmd("uplevel", stdlib.RunUplevel())
+	set.registerCmd("subst", stdlib.RunSubst())
 	set.registerCmd("incr", stdlib.RunIncr())
 	set.registerCmd("decr", stdlib.RunDecr())
 	set.registerCmd("namespace", stdlib.MakeNamespace())
 	set.registerCmd("variable", stdlib.RunVariable())
 	set.registerCmd("parray", stdlib.PrintArray())
 	set.registerCmd("array", stdlib.MakeArray())
+	set.registerCmd("dict", stdlib.MakeDict())
 	set.registerCmd("info", stdlib.MakeInfo())
 	set.registerCmd("clock", stdlib.MakeClock())
+	set.registerCmd("encoding", stdlib.MakeEncoding())
+	set.registerCmd("binary", stdlib.MakeBinary())
 	set.registerCmd("append", stdlib.RunAppend())
+	set.registerCmd("trace", stdlib.MakeTrace())
 	set.registerCmd("rename", stdlib.RunRename())
 	set.registerCmd("global", stdlib.RunGlobal())
 	set.registerCmd("time", stdlib.RunTime())
 	set.registerCmd("exit", stdlib.RunExit())
 	set.registerCmd("cd", stdlib.RunChdir())
 	set.registerCmd("pid", stdlib.RunPid())
+	set.registerCmd("exec", stdlib.RunExec())
 	set.registerCmd("pwd", stdlib.RunPwd())
 	set.registerCmd("try", stdlib.RunTry())
 	set.registerCmd("throw", stdlib.RunThrow())
@@ -68,6 +82,14 @@ func DefaultSet() CommandSet {
 	set.registerCmd("gets", stdlib.RunGets())
 	set.registerCmd("read", stdlib.RunRead())
 	set.registerCmd("chan", stdlib.MakeChan())
+	set.registerCmd("fconfigure", stdlib.RunFConfigure())
+	set.registerCmd("flush", stdlib.RunFlush())
+	set.registerCmd("fblocked", stdlib.RunFBlocked())
+	set.registerCmd("socket", stdlib.RunSocket())
+	set.registerCmd("fileevent", stdlib.RunFileEvent())
+	set.registerCmd("after", stdlib.RunAfter())
+	set.registerCmd("vwait", stdlib.RunVwait())
+	set.registerCmd("update", stdlib.RunUpdate())
 	set.registerCmd("fcopy", stdlib.RunFCopy())
 	set.registerCmd("file", stdlib.MakeFile())
 	set.registerCmd("list", stdlib.RunList())
@@ -189,7 +211,7 @@ func (cs CommandSet) Rename(prev, next string) error {
 func (cs CommandSet) Procedures() []string {
 	var list []string
 	for k, e := range cs {
-		if _, ok := e.(procedure); !ok {
+		if _, ok := unwrapCommand(e).(procedure); !ok {
 			continue
 		}
 		list = append(list, k)
@@ -202,7 +224,7 @@ func (cs CommandSet) Args(proc string) ([]string, error) {
 	if !ok {
 		return nil, undefinedProc(proc)
 	}
-	p, ok := e.(procedure)
+	p, ok := unwrapCommand(e).(procedure)
 	if !ok {
 		return nil, notaProcedure(proc, "can not get arguments")
 	}
@@ -218,7 +240,7 @@ func (cs CommandSet) Body(proc string) (string, error) {
 	if !ok {
 		return "", undefinedProc(proc)
 	}
-	p, ok := e.(procedure)
+	p, ok := unwrapCommand(e).(procedure)
 	if !ok {
 		return "", notaProcedure(proc, "can not get body")
 	}
@@ -234,6 +256,7 @@ type procedure struct {
 	Body     string
 	Args     []argument
 	Variadic bool
+	script   *script
 }
 
 func createProcedure(name, body, args string) (stdlib.Executer, error) {
@@ -241,6 +264,7 @@ func createProcedure(name, body, args string) (stdlib.Executer, error) {
 		Name: name,
 		Body: strings.TrimSpace(body),
 	}
+	p.script = compile(body)
 	args = strings.TrimSpace(args)
 	if len(args) != 0 {
 		as, err := parseArguments(args)
@@ -269,14 +293,69 @@ func (_ procedure) IsSafe() bool {
 
 func (p procedure) Execute(i stdlib.Interpreter, args []env.Value) (env.Value, error) {
 	for j, a := range p.Args {
+		if p.Variadic && j == len(p.Args)-1 {
+			var rest []env.Value
+			if j < len(args) {
+				rest = args[j:]
+			}
+			i.Define(a.Name, env.ListFrom(rest...))
+			break
+		}
 		if j < len(args) {
 			a.Default = args[j]
 		}
+		if a.Default == nil {
+			return nil, p.wrongArgs()
+		}
 		i.Define(a.Name, a.Default)
 	}
+	if !p.Variadic && len(args) > len(p.Args) {
+		return nil, p.wrongArgs()
+	}
+	if x, ok := i.(*Interpreter); ok {
+		return x.run(p.script)
+	}
 	return i.Execute(strings.NewReader(p.Body))
 }
 
+func (p procedure) wrongArgs() error {
+	list := []string{p.Name}
+	for j, a := range p.Args {
+		switch {
+		case p.Variadic && j == len(p.Args)-1:
+			list = append(list, "?arg ...?")
+		case a.Default != nil:
+			list = append(list, "?"+a.Name+"?")
+		default:
+			list = append(list, a.Name)
+		}
+	}
+	return fmt.Errorf("wrong # args: should be %q", strings.Join(list, " "))
+}
+
+type lambda struct {
+	procedure
+	ns []string
+}
+
+func createLambda(v env.Value) (lambda, error) {
+	var fn lambda
+	list, err := env.ToStringList(v)
+	if err != nil || len(list) < 2 || len(list) > 3 {
+		return fn, fmt.Errorf("can't interpret %q as a lambda expression", v.String())
+	}
+	exec, err := createProcedure("apply", slices.Snd(list), slices.Fst(list))
+	if err != nil {
+		return fn, err
+	}
+	fn.procedure = exec.(procedure)
+	fn.Name = "apply lambdaExpr"
+	if len(list) == 3 {
+		fn.ns = strings.Split(strings.TrimPrefix(slices.Lst(list), "::"), "::")
+	}
+	return fn, nil
+}
+
 type argument struct {
 	Name    string
 	Default env.Value
@@ -1,13 +1,10 @@
 package interp
 
 import (
-	"bytes"
-	"errors"
 	"fmt"
 	"io"
 	"os"
 	"strconv"
-	"strings"
 )
 
 const (
@@ -16,7 +13,10 @@ const (
 	stderr = "stderr"
 )
 
-const fdprefix = "file"
+const (
+	fdprefix   = "file"
+	sockprefix = "sock"
+)
 
 const (
 	modeReadOnly   = "r"
@@ -28,17 +28,26 @@ const (
 )
 
 type Fileset struct {
-	files map[string]*os.File
+	files map[string]*Channel
 	next  int
 }
 
 func Stdio() *Fileset {
 	fs := Fileset{
-		files: make(map[string]*os.File),
+		files: make(map[string]*Channel),
 	}
-	fs.register("0", os.Stdin)
-	fs.register("1", os.Stdout)
-	fs.register("2", os.Stderr)
+	var (
+		in  = newChannel(os.Stdin, true, false)
+		out = newChannel(os.Stdout, false, true)
+		err = newChannel(os.Stderr, false, true)
+	)
+	in.buffering = bufferLine
+	out.buffering = bufferLine
+	err.buffering = bufferNone
+
+	fs.register("0", in)
+	fs.register("1", out)
+	fs.register("2", err)
 
 	return &fs
 }
@@ -61,58 +70,108 @@ func (fs *Fileset) Channels() []string {
 }
 
 func (fs *Fileset) Print(fd, str string) error {
-	w, err := fs.lookup(fd)
+	c, err := fs.lookup(fd)
 	if err != nil {
 		return err
 	}
-	fmt.Fprint(w, str)
-	return nil
+	_, err = io.WriteString(c, str)
+	return err
 }
 
 func (fs *Fileset) Println(fd, str string) error {
-	if err := fs.Print(fd, str); err != nil {
-		return err
-	}
-	w, err := fs.lookup(fd)
-	if err != nil {
-		return err
-	}
-	fmt.Fprintln(w)
-	return nil
+	return fs.Print(fd, str+"\n")
 }
 
 func (fs *Fileset) Open(file, mode string) (string, error) {
 	var (
 		f   *os.File
 		err error
+		rd  bool
+		wr  bool
 	)
 	switch mode {
 	default:
 		return "", fmt.Errorf("%s: unknown mode given", mode)
 	case modeReadOnly, "":
 		f, err = os.Open(file)
+		rd = true
 	case modeReadBoth:
+		f, err = os.OpenFile(file, os.O_RDWR, 0)
+		rd, wr = true, true
 	case modeWriteOnly:
 		f, err = os.Create(file)
+		wr = true
 	case modeWriteBoth:
+		f, err = os.OpenFile(file, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0666)
+		rd, wr = true, true
 	case modeAppendOnly:
+		f, err = os.OpenFile(file, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0666)
+		wr = true
 	case modeAppendBoth:
+		f, err = os.OpenFile(file, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0666)
+		rd, wr = true, true
 	}
 	if err != nil {
 		return "", err
 	}
-	fd := fdprefix + strconv.Itoa(fs.next)
-	fs.register(fd, f)
-	return fd, nil
+	return fs.attach(fdprefix, newChannel(f, rd, wr)), nil
+}
+
+func (fs *Fileset) Attach(rw io.ReadWriteCloser) string {
+	return fs.attach(fdprefix, newChannel(rw, true, true))
+}
+
+func (fs *Fileset) attach(prefix string, c *Channel) string {
+	fd := prefix + strconv.Itoa(fs.next)
+	fs.register(fd, c)
+	return fd
+}
+
+func (fs *Fileset) Pids(fd string) ([]int, error) {
+	c, err := fs.lookup(fd)
+	if err != nil {
+		return nil, err
+	}
+	p, ok := c.rw.(interface{ Pids() []int })
+	if !ok {
+		return nil, nil
+	}
+	return p.Pids(), nil
 }
 
 func (fs *Fileset) Close(fd string) error {
-	w, err := fs.lookup(fd)
+	c, err := fs.lookup(fd)
 	if err != nil {
 		return err
 	}
-	delete(fs.files, fd)
-	return w.Close()
+	for k, v := range fs.files {
+		if v == c {
+			delete(fs.files, k)
+		}
+	}
+	return c.Close()
+}
+
+func (fs *Fileset) CloseRead(fd string) error {
+	c, err := fs.lookup(fd)
+	if err != nil {
+		return err
+	}
+	if !c.writable {
+		return fs.Close(fd)
+	}
+	return c.CloseRead()
+}
+
+func (fs *Fileset) CloseWrite(fd string) error {
+	c, err := fs.lookup(fd)
+	if err != nil {
+		return err
+	}
+	if !c.readable {
+		return fs.Close(fd)
+	}
+	return c.CloseWrite()
 }
 
 func (fs *Fileset) Copy(src, dst string, size int) (int64, error) {
@@ -124,18 +183,28 @@ func (fs *Fileset) Copy(src, dst string, size int) (int64, error) {
 	if err != nil {
 		return 0, err
 	}
+	defer w.Flush()
 	if size <= 0 {
 		return io.Copy(w, r)
 	}
-	return io.CopyN(w, r, int64(size))
+	str, err := r.ReadChars(size)
+	if err != nil {
+		return 0, err
+	}
+	n, err := io.WriteString(w, str)
+	return int64(n), err
 }
 
 func (fs *Fileset) Seek(fd string, offset, whence int) (int64, error) {
-	w, err := fs.lookup(fd)
+	c, err := fs.lookup(fd)
 	if err != nil {
 		return 0, err
 	}
-	return w.Seek(int64(offset), whence)
+	tell, err := c.Seek(int64(offset), whence)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", fd, err)
+	}
+	return tell, nil
 }
 
 func (fs *Fileset) Tell(fd string) (int64, error) {
@@ -143,89 +212,122 @@ func (fs *Fileset) Tell(fd string) (int64, error) {
 }
 
 func (fs *Fileset) Gets(fd string) (string, error) {
-	w, err := fs.lookup(fd)
+	c, err := fs.lookup(fd)
 	if err != nil {
 		return "", err
 	}
-	var (
-		buf = make([]byte, 4096)
-		ret []byte
-	)
-	off, err := w.Seek(0, io.SeekCurrent)
+	return c.ReadLine()
+}
+
+func (fs *Fileset) Read(fd string, length int) (string, error) {
+	c, err := fs.lookup(fd)
 	if err != nil {
 		return "", err
 	}
-	for {
-		n, err := w.Read(buf)
-		if err != nil && !errors.Is(err, io.EOF) {
-			return "", err
-		}
-		if n == 0 {
-			break
-		}
-		if x := bytes.IndexByte(buf[:n], '\n'); x >= 0 {
-			ret = append(ret, buf[:x]...)
-			if _, err = w.Seek(off+int64(x+1), io.SeekStart); err != nil {
-				return "", err
-			}
-			break
-		} else {
-			ret = append(ret, buf[:n]...)
-		}
+	return c.ReadChars(length)
+}
+
+func (fs *Fileset) Eof(fd string) (bool, error) {
+	c, err := fs.lookup(fd)
+	if err != nil {
+		return false, err
 	}
-	return strings.TrimSpace(string(ret)), nil
+	return c.Eof(), nil
 }
 
-func (fs *Fileset) Read(fd string, length int) (string, error) {
-	w, err := fs.lookup(fd)
+func (fs *Fileset) Blocked(fd string) (bool, error) {
+	c, err := fs.lookup(fd)
 	if err != nil {
-		return "", err
+		return false, err
 	}
-	var b []byte
-	if length <= 0 {
-		b, err = io.ReadAll(w)
-		return string(b), err
+	return c.Blocked(), nil
+}
+
+func (fs *Fileset) Flush(fd string) error {
+	c, err := fs.lookup(fd)
+	if err != nil {
+		return err
 	}
-	b = make([]byte, length)
-	n, err := io.ReadFull(w, b)
-	if err == nil || errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, io.EOF) {
-		return string(b[:n]), nil
+	return c.Flush()
+}
+
+func (fs *Fileset) FlushAll() error {
+	var err error
+	for _, c := range fs.files {
+		if e := c.Flush(); e != nil {
+			err = e
+		}
 	}
-	return "", err
+	return err
 }
 
-func (fs *Fileset) Eof(fd string) (bool, error) {
-	w, err := fs.lookup(fd)
+func (fs *Fileset) ConfigNames(fd string) ([]string, error) {
+	c, err := fs.lookup(fd)
 	if err != nil {
-		return false, err
+		return nil, err
 	}
-	tell, err := w.Seek(0, io.SeekCurrent)
+	return c.Names(), nil
+}
+
+func (fs *Fileset) Config(fd, option string) ([]string, error) {
+	c, err := fs.lookup(fd)
 	if err != nil {
-		return false, err
+		return nil, err
 	}
-	s, err := w.Stat()
+	return c.Get(option)
+}
+
+func (fs *Fileset) Configure(fd, option string, values []string) error {
+	c, err := fs.lookup(fd)
 	if err != nil {
-		return false, err
+		return err
+	}
+	return c.Set(option, values)
+}
+
+func (fs *Fileset) Reader(fd string) (io.Reader, error) {
+	c, err := fs.lookup(fd)
+	if err != nil {
+		return nil, err
+	}
+	if f, ok := c.rw.(*os.File); ok && c.chunks == nil && c.text == "" && len(c.raw) == 0 {
+		return f, nil
+	}
+	return c, nil
+}
+
+func (fs *Fileset) Writer(fd string) (io.Writer, error) {
+	c, err := fs.lookup(fd)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.Flush(); err != nil {
+		return nil, err
+	}
+	if f, ok := c.rw.(*os.File); ok && c.outTrans == transLf && c.encoding == "utf-8" {
+		return f, nil
 	}
-	return tell == s.Size(), nil
+	return c, nil
 }
 
-func (fs *Fileset) register(fd string, f *os.File) {
-	fs.files[fd] = f
+func (fs *Fileset) register(fd string, c *Channel) {
+	fs.files[fd] = c
 	fs.next++
 }
 
-func (fs *Fileset) lookup(fd string) (*os.File, error) {
+func (fs *Fileset) lookup(fd string) (*Channel, error) {
 	switch fd {
+	case stdin:
+		fd = "0"
 	case stdout, "":
 		fd = "1"
 	case stderr:
 		fd = "2"
 	default:
 	}
-	w, ok := fs.files[fd]
+	c, ok := fs.files[fd]
 	if !ok {
 		return nil, fmt.Errorf("%s: undefined channel", fd)
 	}
-	return w, nil
+	return c, nil
 }
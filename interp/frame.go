@@ -1,8 +1,12 @@
 package interp
 
 import (
+	"errors"
+	"fmt"
+
 	"github.com/midbel/gotcl/env"
 	"github.com/midbel/gotcl/stdlib"
+	"github.com/midbel/slices"
 )
 
 type Frame struct {
@@ -33,3 +37,82 @@ func (f *Frame) Resolve(n string) (env.Value, error) {
 	}
 	return f.ns.Resolve(n)
 }
+
+type callFrame struct {
+	line  int
+	cmd   string
+	file  string
+	frame *Frame
+	level int
+}
+
+func (c callFrame) proc() string {
+	if c.frame == nil || c.frame.cmd == nil {
+		return ""
+	}
+	return qualifiedName(c.frame.ns, c.frame.cmd.GetName())
+}
+
+func (i *Interpreter) pushCall(c compiledCommand) int {
+	n := len(i.calls)
+	i.calls = append(i.calls, callFrame{
+		line:  c.line,
+		cmd:   c.text,
+		file:  i.file,
+		frame: i.currentFrame(),
+		level: i.Depth() - 1,
+	})
+	return n
+}
+
+func (i *Interpreter) traceError(err error, c compiledCommand) error {
+	e, ok := errorTrace(err)
+	if !ok {
+		return err
+	}
+	if e.ErrorInfo == "" {
+		e.ErrorInfo = fmt.Sprintf("%s\n    while executing\n\"%s\"", e.Error(), c.text)
+		e.ErrorStack = append(e.ErrorStack, env.Str("INNER"), env.Str(c.text))
+	} else {
+		e.ErrorInfo = fmt.Sprintf("%s\n    invoked from within\n\"%s\"", e.ErrorInfo, c.text)
+	}
+	e.ErrorLine = c.line
+	return e
+}
+
+func (i *Interpreter) traceProc(err error, name string, args []env.Value) error {
+	e, ok := errorTrace(err)
+	if !ok || e.ErrorInfo == "" {
+		return err
+	}
+	e.ErrorInfo = fmt.Sprintf("%s\n    (procedure \"%s\" line %d)", e.ErrorInfo, name, e.ErrorLine)
+	e.ErrorStack = append(e.ErrorStack, env.Str("CALL"), env.ListFrom(slices.Prepend(env.Str(name), args)...))
+	return e
+}
+
+func (i *Interpreter) traceFile(err error, file string) error {
+	e, ok := errorTrace(err)
+	if !ok || e.ErrorInfo == "" {
+		return err
+	}
+	e.ErrorInfo = fmt.Sprintf("%s\n    (file \"%s\" line %d)", e.ErrorInfo, file, e.ErrorLine)
+	return e
+}
+
+func errorTrace(err error) (stdlib.Error, bool) {
+	if returnCode(err) != stdlib.ErrorErr || errors.Is(err, stdlib.ErrExit) {
+		return stdlib.Error{}, false
+	}
+	e, ok := err.(stdlib.Error)
+	if ok {
+		return e, e.Code == stdlib.ErrorErr
+	}
+	if errors.As(err, &e) {
+		if e.Code != stdlib.ErrorErr {
+			return e, false
+		}
+		e.Err = err
+		return e, true
+	}
+	return stdlib.Error{Err: err, Code: stdlib.ErrorErr}, true
+}
@@ -8,8 +8,10 @@ import (
 	"strings"
 
 	"github.com/midbel/gotcl/env"
+	"github.com/midbel/gotcl/expr"
 	"github.com/midbel/gotcl/glob"
 	"github.com/midbel/gotcl/stdlib"
+	"github.com/midbel/gotcl/word"
 	"github.com/midbel/slices"
 )
 
@@ -24,8 +26,18 @@ type Interpreter struct {
 	count  int
 	safe   bool
 	frames []*Frame
+	calls  []callFrame
+	file   string
 
 	*Fileset
+	events  *eventLoop
+	coro    *coroutine
+	scripts map[string]*script
+	exprs   *expr.Cache
+
+	tracing bool
+	vtraces int
+	steps   []*tracedCommand
 
 	name     string
 	parent   *Interpreter
@@ -41,6 +53,9 @@ func defaultInterpreter(name string, safe bool) *Interpreter {
 		safe:    safe,
 		name:    name,
 		Fileset: Stdio(),
+		events:  createLoop(),
+		scripts: make(map[string]*script),
+		exprs:   expr.NewCache(expr.DefaultCacheSize),
 	}
 	i.pushDefault(GlobalNS())
 	return &i
@@ -61,9 +76,23 @@ func (i *Interpreter) Locals(pat string) []string {
 }
 
 func (i *Interpreter) RegisterNS(name, body string) error {
-	ns := emptyNS(name)
-	if err := i.currentNS().RegisterNS(ns); err != nil {
-		return err
+	var (
+		parts = strings.Split(name, "::")
+		ns    = i.currentNS()
+	)
+	if slices.Fst(parts) == "" {
+		parts = slices.Rest(parts)
+		ns = i.rootNS()
+	}
+	for _, p := range parts {
+		child, err := ns.lookupNS(p)
+		if err != nil {
+			child = emptyNS(p)
+			if err := ns.RegisterNS(child); err != nil {
+				return err
+			}
+		}
+		ns = child
 	}
 	i.pushDefault(ns)
 	defer i.pop()
@@ -99,7 +128,7 @@ func (i *Interpreter) ParentNS(n string) (string, error) {
 	return ns.FQN(), nil
 }
 
-func (i *Interpreter) ChildreNS(n string) ([]string, error) {
+func (i *Interpreter) ChildrenNS(n string) ([]string, error) {
 	var (
 		name    = strings.Split(n, "::")
 		ns, err = i.rootNS().LookupNS(name)
@@ -130,6 +159,9 @@ func (i *Interpreter) LinkVar(src, dst string, level int) error {
 	if depth < level {
 		return fmt.Errorf("can not link variables in level %d", level)
 	}
+	if _, _, ok := word.SplitIndex(dst); ok {
+		return fmt.Errorf("bad variable name %q: can't create a scalar variable that looks like an array element", dst)
+	}
 	depth -= level
 	i.currentFrame().Define(dst, env.NewLink(src, depth))
 	return nil
@@ -246,74 +278,303 @@ func (i *Interpreter) RegisterDefer(body string) error {
 }
 
 func (i *Interpreter) RegisterProc(name, body, args string) error {
-	exec, err := createProcedure(name, body, args)
+	var (
+		parts = strings.Split(name, "::")
+		ns    = i.currentNS()
+		err   error
+	)
+	if slices.Fst(parts) == "" {
+		parts = slices.Rest(parts)
+		ns = i.rootNS()
+	}
+	if len(parts) > 1 {
+		ns, err = ns.LookupNS(slices.Slice(parts))
+		if err != nil {
+			return err
+		}
+	}
+	exec, err := createProcedure(slices.Lst(parts), body, args)
 	if err == nil {
-		i.currentNS().RegisterExec([]string{name}, exec)
+		ns.RegisterExec([]string{slices.Lst(parts)}, exec)
 	}
 	return err
 }
 
+func (i *Interpreter) Call(name string, args []env.Value) (env.Value, error) {
+	return i.execute(&Command{
+		Name: env.Str(name),
+		Args: args,
+	})
+}
+
+func (i *Interpreter) Subst(str string, flags int) (env.Value, error) {
+	parts, err := compileSubst(str, flags, 0)
+	if err != nil {
+		return nil, err
+	}
+	if len(parts) == 0 {
+		return env.EmptyStr(), nil
+	}
+	return substituteTokens(parts, i)
+}
+
+func (i *Interpreter) Apply(fn env.Value, args []env.Value) (env.Value, error) {
+	x, err := env.Internal(fn, "lambda", func(str string) (any, error) {
+		return createLambda(env.Str(str))
+	})
+	if err != nil {
+		return nil, err
+	}
+	exec := x.(lambda)
+	ns, err := i.rootNS().LookupNS(exec.ns)
+	if err != nil {
+		return nil, err
+	}
+	i.pushDefault(ns)
+	defer i.executeDefer()
+
+	f := i.currentFrame()
+	f.cmd = exec
+	f.args = args
+	res, err := exec.Execute(i, args)
+	if err != nil {
+		err = i.traceProc(err, exec.GetName(), args)
+	}
+	return res, err
+}
+
 func (i *Interpreter) Define(n string, v env.Value) {
+	if err := i.define(n, v); err == nil {
+		i.traceVar(n, "write")
+	}
+}
+
+func (i *Interpreter) SetVar(n string, v env.Value) (env.Value, error) {
+	if err := i.define(n, v); err != nil {
+		return nil, err
+	}
+	if err := i.traceVar(n, "write"); err != nil {
+		return nil, err
+	}
+	return i.resolve(n)
+}
+
+func (i *Interpreter) define(n string, v env.Value) error {
+	if name, key, ok := word.SplitIndex(n); ok {
+		arr, err := i.resolve(name)
+		if err != nil {
+			arr = env.EmptyArr()
+		}
+		a, ok := arr.(env.Array)
+		if !ok {
+			return fmt.Errorf("can't set %q: variable isn't array", n)
+		}
+		a.Set(key, v)
+		return i.define(name, a)
+	}
+	if strings.Contains(n, "::") {
+		e, name, err := i.qualifiedEnv(n)
+		if err != nil {
+			return err
+		}
+		if tmp, err := e.Resolve(name); err == nil {
+			if k, ok := tmp.(env.Link); ok {
+				return i.defineLink(k, v)
+			}
+		}
+		e.Define(name, v)
+		if e == i.rootFrame().env {
+			i.events.touch(name)
+		}
+		return nil
+	}
 	tmp, err := i.currentFrame().Resolve(n)
 	if err == nil {
 		k, ok := tmp.(env.Link)
 		if ok {
-			i.frames[k.At()].env.Define(k.String(), v)
-			return
+			return i.defineLink(k, v)
 		}
 	}
 	i.currentFrame().Define(n, v)
+	if len(i.frames) == 1 {
+		i.events.touch(n)
+	}
+	return nil
+}
+
+func (i *Interpreter) defineLink(k env.Link, v env.Value) error {
+	var (
+		e              = i.frames[k.At()].env
+		name, key, arr = word.SplitIndex(k.String())
+	)
+	if arr {
+		x, err := e.Resolve(name)
+		if err != nil {
+			x = env.EmptyArr()
+		}
+		a, ok := x.(env.Array)
+		if !ok {
+			return fmt.Errorf("can't set %q: variable isn't array", k.String())
+		}
+		a.Set(key, v)
+		v = a
+	}
+	e.Define(name, v)
+	if k.At() == 0 {
+		i.events.touch(name)
+	}
+	return nil
 }
 
 func (i *Interpreter) Delete(n string) {
+	if name, key, ok := word.SplitIndex(n); ok {
+		if a, ok := i.lookupArray(name); ok {
+			a.Unset(key)
+		}
+		i.traceVar(n, "unset")
+		return
+	}
 	v, err := i.currentFrame().Resolve(n)
 	if err == nil {
 		k, ok := v.(env.Link)
 		if ok {
-			i.frames[k.At()].env.Delete(k.String())
+			name, key, arr := word.SplitIndex(k.String())
+			e := i.frames[k.At()].env
+			if !arr {
+				e.Delete(name)
+			} else if x, err := e.Resolve(name); err == nil {
+				if a, ok := x.(env.Array); ok {
+					a.Unset(key)
+				}
+			}
+			if k.At() == 0 {
+				i.events.touch(name)
+			}
+		}
+	}
+	if strings.Contains(n, "::") {
+		if e, name, err := i.qualifiedEnv(n); err == nil {
+			e.Delete(name)
+			if e == i.rootFrame().env {
+				i.events.touch(name)
+			}
 		}
+		i.traceVar(n, "unset")
+		return
 	}
 	i.currentFrame().Delete(n)
+	if len(i.frames) == 1 {
+		i.events.touch(n)
+	}
+	i.traceVar(n, "unset")
 }
 
 func (i *Interpreter) Rename(prev, next string) error {
-	return i.currentNS().Rename(prev, next)
+	exec := i.currentNS().CommandSet[prev]
+	if err := i.currentNS().Rename(prev, next); err != nil {
+		return err
+	}
+	i.traceCommand(exec, qualifiedName(i.currentNS(), prev), next)
+	if next == "" {
+		i.killCoroutine(unwrapCommand(exec))
+	}
+	return nil
 }
 
 func (i *Interpreter) Resolve(n string) (env.Value, error) {
-	name := strings.Split(n, "::")
-	if len(name) == 1 {
-		v, err := i.currentFrame().Resolve(n)
+	if err := i.traceVar(n, "read"); err != nil {
+		return nil, err
+	}
+	return i.resolve(n)
+}
+
+func (i *Interpreter) resolve(n string) (env.Value, error) {
+	if name, key, ok := word.SplitIndex(n); ok {
+		arr, err := i.resolve(name)
+		if err != nil {
+			return nil, fmt.Errorf("can't read %q: no such variable", n)
+		}
+		return arrayElement(n, arr, key)
+	}
+	var (
+		v   env.Value
+		err error
+	)
+	if strings.Contains(n, "::") {
+		e, name, err := i.qualifiedEnv(n)
 		if err != nil {
 			return nil, err
 		}
-		if k, ok := v.(env.Link); ok {
-			v, err = i.frames[k.At()].env.Resolve(k.String())
+		if v, err = e.Resolve(name); err != nil {
+			return nil, err
 		}
-		return v, err
+	} else {
+		v, err = i.currentFrame().Resolve(n)
 	}
+	if err != nil {
+		return nil, err
+	}
+	if k, ok := v.(env.Link); ok {
+		v, err = i.resolveLink(k)
+	}
+	return v, err
+}
+
+// qualifiedEnv gives the environment where the qualified variable n lives
+// and the name of the variable in it. The variables of the global namespace
+// are the ones of the global frame.
+func (i *Interpreter) qualifiedEnv(n string) (*env.Env, string, error) {
 	var (
-		ps  = slices.Slice(name)
-		vs  = slices.Lst(name)
-		ns  *Namespace
-		err error
+		name = strings.Split(n, "::")
+		ps   = slices.Slice(name)
+		vs   = slices.Lst(name)
+		ns   *Namespace
+		err  error
 	)
+	if len(ps) == 1 && ps[0] == "" {
+		return i.rootFrame().env, vs, nil
+	}
 	if ps[0] == "" {
 		ns, err = i.rootNS().LookupNS(ps[1:])
 	} else {
 		ns, err = i.currentNS().LookupNS(ps)
 	}
 	if err != nil {
-		return nil, err
+		return nil, "", err
 	}
-	v, err := ns.Resolve(vs)
+	return ns.env, vs, nil
+}
+
+func (i *Interpreter) resolveLink(k env.Link) (env.Value, error) {
+	var (
+		e              = i.frames[k.At()].env
+		name, key, arr = word.SplitIndex(k.String())
+	)
+	v, err := e.Resolve(name)
+	if err != nil || !arr {
+		return v, err
+	}
+	return arrayElement(k.String(), v, key)
+}
+
+func (i *Interpreter) lookupArray(name string) (env.Array, bool) {
+	v, err := i.resolve(name)
 	if err != nil {
-		return nil, err
+		return env.Array{}, false
 	}
-	if k, ok := v.(env.Link); ok {
-		v, err = i.frames[k.At()].env.Resolve(k.String())
+	a, ok := v.(env.Array)
+	return a, ok
+}
+
+func arrayElement(name string, v env.Value, key string) (env.Value, error) {
+	a, ok := v.(env.Array)
+	if !ok {
+		return nil, fmt.Errorf("can't read %q: variable isn't array", name)
 	}
-	return v, err
+	if v = a.Get(key); v == nil {
+		return nil, fmt.Errorf("can't read %q: no such element in array", name)
+	}
+	return v, nil
 }
 
 func (i *Interpreter) Commands(pat string) []string {
@@ -366,48 +627,150 @@ func (i Interpreter) IsComplete(cmd string) bool {
 }
 
 func (i *Interpreter) CurrentFrame(level int) (string, []string, error) {
+	depth := i.Depth() - 1
+	if level <= 0 {
+		level += depth
+	}
+	if level <= 0 || level > depth {
+		return "", nil, fmt.Errorf("bad level \"%d\"", level)
+	}
 	var (
-		f  = i.currentFrame()
+		f  = i.frames[level]
 		as []string
 	)
+	if f.cmd == nil {
+		return "namespace", []string{"eval", f.ns.FQN()}, nil
+	}
 	for i := range f.args {
 		as = append(as, f.args[i].String())
 	}
 	return f.cmd.GetName(), as, nil
 }
 
+func (i *Interpreter) FrameDepth() int {
+	return len(i.calls)
+}
+
+func (i *Interpreter) FrameInfo(level int) (env.Value, error) {
+	if level <= 0 {
+		level += len(i.calls)
+	}
+	if level <= 0 || level > len(i.calls) {
+		return nil, fmt.Errorf("bad level \"%d\"", level)
+	}
+	var (
+		c      = i.calls[level-1]
+		keys   = []string{"type", "line", "cmd"}
+		values = []env.Value{env.Str("eval"), env.Int(int64(c.line)), env.Str(c.cmd)}
+	)
+	if proc := c.proc(); proc != "" {
+		values[0] = env.Str("proc")
+		keys = append(keys, "proc")
+		values = append(values, env.Str(proc))
+	} else if c.file != "" {
+		values[0] = env.Str("source")
+		keys = append(keys, "file")
+		values = append(values, env.Str(c.file))
+	}
+	keys = append(keys, "level")
+	values = append(values, env.Int(int64(i.Depth()-1-c.level)))
+	return env.ZipDict(keys, values), nil
+}
+
 func (i *Interpreter) Execute(r io.Reader) (env.Value, error) {
-	if i.currentNS().Root() && i.count == 0 {
-		defer i.executeDefer()
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
 	}
-	p, err := New(r)
+	n, ok := r.(interface{ Name() string })
+	if !ok {
+		return i.run(i.compile(string(b)))
+	}
+	file := i.file
+	i.file = n.Name()
+	defer func() {
+		i.file = file
+	}()
+	res, err := i.run(i.compile(string(b)))
+	if err != nil {
+		err = i.traceFile(err, n.Name())
+	}
+	return res, err
+}
+
+func (i *Interpreter) ExecuteValue(v env.Value) (env.Value, error) {
+	s, err := env.Internal(v, "script", func(str string) (any, error) {
+		return i.compile(str), nil
+	})
 	if err != nil {
 		return nil, err
 	}
-	for i.err != nil {
-		c, err := p.Parse(i)
-		if err != nil {
-			if errors.Is(err, io.EOF) {
-				break
-			}
-			return nil, err
+	return i.run(s.(*script))
+}
+
+func (i *Interpreter) CompileExpr(str string) (expr.Expression, error) {
+	return i.exprs.Get(str)
+}
+
+func (i *Interpreter) compile(str string) *script {
+	if s, ok := i.scripts[str]; ok {
+		return s
+	}
+	s := compile(str)
+	if len(i.scripts) >= maxScripts {
+		i.scripts = make(map[string]*script)
+	}
+	i.scripts[str] = s
+	return s
+}
+
+func (i *Interpreter) run(s *script) (env.Value, error) {
+	if i.currentNS().Root() && i.count == 0 {
+		defer i.executeDefer()
+	}
+	i.last, i.err = env.EmptyStr(), nil
+	for _, c := range s.cmds {
+		n := i.pushCall(c)
+		cmd, err := c.substitute(i)
+		switch {
+		case err != nil:
+			i.last, i.err = nil, err
+		case cmd == nil:
+			i.last, i.err = env.EmptyStr(), nil
+		default:
+			i.last, i.err = i.execute(cmd)
 		}
-		i.last, i.err = i.execute(c)
+		i.calls = i.calls[:n]
+		if i.err != nil {
+			i.err = i.traceError(i.err, c)
+			return i.last, i.err
+		}
+	}
+	if s.err != nil {
+		return nil, s.err
 	}
 	return i.last, i.err
 }
 
 func (i *Interpreter) execute(c *Command) (env.Value, error) {
+	if i.killedCoroutine() {
+		return nil, errKilled
+	}
 	var (
 		parts = strings.Split(c.Name.String(), "::")
+		base  = i.currentNS()
 		ns    *Namespace
 		err   error
 	)
 	if slices.Fst(parts) == "" {
 		parts = slices.Rest(parts)
+		base = i.rootNS()
+	}
+	if len(parts) == 0 || slices.Lst(parts) == "" {
+		return nil, fmt.Errorf("invalid command name %q", c.Name.String())
 	}
 	if n := len(parts); n > 1 {
-		ns, err = i.currentNS().LookupNS(slices.Slice(parts))
+		ns, err = base.LookupNS(slices.Slice(parts))
 	} else {
 		ns = i.currentNS()
 	}
@@ -436,7 +799,16 @@ func (i *Interpreter) execute(c *Command) (env.Value, error) {
 	defer func() {
 		i.count++
 	}()
-	return exec.Execute(i, c.Args)
+	var res env.Value
+	if len(i.steps) > 0 && !i.tracing {
+		res, err = i.executeStep(exec, c)
+	} else {
+		res, err = exec.Execute(i, c.Args)
+	}
+	if err != nil && exec.Scoped() {
+		err = i.traceProc(err, c.Name.String(), c.Args)
+	}
+	return res, err
 }
 
 func (i *Interpreter) isSafe(exec stdlib.Executer) bool {
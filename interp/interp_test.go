@@ -0,0 +1,246 @@
+package interp
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+type scriptTest struct {
+	Script string
+	Want   string
+}
+
+func TestIncr(t *testing.T) {
+	data := []scriptTest{
+		{Script: "set x 1\nincr x", Want: "2"},
+		{Script: "set x 1\nincr x 5", Want: "6"},
+		{Script: "set x 1\nincr x -3", Want: "-2"},
+		{Script: "set x 1\nincr x\nincr x", Want: "3"},
+		{Script: "set x 9007199254740993\nincr x", Want: "9007199254740994"},
+		{Script: "set x 9223372036854775807\nincr x", Want: "9223372036854775808"},
+		{Script: "set x -9223372036854775808\nincr x -1", Want: "-9223372036854775809"},
+		{Script: "set x 10\ndecr x 3", Want: "7"},
+		{Script: "set x 1\ncatch {incr x 1.5} msg\nset msg", Want: "expected integer but got \"1.5\""},
+		{Script: "set x 1.0\ncatch {incr x} msg\nset msg", Want: "expected integer but got \"1.0\""},
+	}
+	runScripts(t, data)
+}
+
+func TestNumbers(t *testing.T) {
+	data := []scriptTest{
+		{Script: "expr {6.0 / 2}", Want: "3.0"},
+		{Script: "set x [expr {6.0 / 2}]\nexpr {[string trim \"$x \"] / 4}", Want: "0.75"},
+		{Script: "expr {abs(-123456789012345678901234567890)}", Want: "123456789012345678901234567890"},
+		{Script: "expr {abs(-3)}", Want: "3"},
+		{Script: "expr {abs(-3.5)}", Want: "3.5"},
+		{Script: "expr {round(2.5)}", Want: "3"},
+		{Script: "format %lld 123456789012345678901234567890", Want: "123456789012345678901234567890"},
+		{Script: "format %ld 9223372036854775808", Want: "-9223372036854775808"},
+		{Script: "format %llx 123456789012345678901234567890", Want: "18ee90ff6c373e0ee4e3f0ad2"},
+	}
+	runScripts(t, data)
+}
+
+func runScripts(t *testing.T, data []scriptTest) {
+	t.Helper()
+	for _, d := range data {
+		t.Run(d.Script, func(t *testing.T) {
+			got, err := Interpret().Execute(strings.NewReader(d.Script))
+			if err != nil {
+				t.Fatalf("execution error: %s", err)
+			}
+			if got.String() != d.Want {
+				t.Errorf("results mismatched! want %s, got %s", d.Want, got)
+			}
+		})
+	}
+}
+
+func TestFileEventRead(t *testing.T) {
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("pipe error: %s", err)
+	}
+	const lines = 50
+	go func() {
+		defer w.Close()
+		for j := 0; j < lines; j++ {
+			fmt.Fprintf(w, "line %d\n", j)
+			time.Sleep(time.Millisecond)
+		}
+	}()
+	var (
+		i      = Interpret()
+		fd     = i.Attach(r)
+		script = `
+set count 0
+set done 0
+proc consume {} {
+	global count done
+	if {[gets %[1]s line] >= 0} {
+		incr count
+	} elseif {[eof %[1]s]} {
+		set done 1
+	}
+}
+proc tick {} {
+	global done
+	consume
+	if {!$done} {
+		after 1 tick
+	}
+}
+fconfigure %[1]s -blocking 0
+fileevent %[1]s readable consume
+after 1 tick
+vwait done
+set count
+`
+	)
+	got, err := i.Execute(strings.NewReader(fmt.Sprintf(script, fd)))
+	if err != nil {
+		t.Fatalf("execution error: %s", err)
+	}
+	if want := strconv.Itoa(lines); got.String() != want {
+		t.Errorf("results mismatched! want %s, got %s", want, got)
+	}
+}
+
+func TestPipelineClose(t *testing.T) {
+	data := []scriptTest{
+		{Script: "set f [open {|tr a-z A-Z} r+]\nputs $f hello\nclose $f write\nset x [gets $f]\nclose $f\nset x", Want: "HELLO"},
+		{Script: "set f [open {|cat} r+]\nputs $f hello\nchan close $f write\nset x [gets $f]\nclose $f read\nset x", Want: "hello"},
+		{Script: "set f [open {|sh -c {echo oops >&2}}]\ncatch {close $f} msg\nset msg", Want: "oops"},
+		{Script: "set f [open {|sh -c {exit 3}}]\ncatch {close $f}", Want: "1"},
+		{Script: "set f [open {|sh -c {echo fine}}]\ngets $f\ncatch {close $f}", Want: "0"},
+	}
+	runScripts(t, data)
+}
+
+func TestApply(t *testing.T) {
+	data := []scriptTest{
+		{Script: "apply {x {expr {$x * 2}}} 3", Want: "6"},
+		{Script: "set f {{x y} {expr {$x - $y}}}\napply $f 5 2\napply $f 7 1", Want: "6"},
+		{Script: "set f {{x {y 10}} {expr {$x + $y}}}\napply $f 1", Want: "11"},
+	}
+	runScripts(t, data)
+}
+
+func TestWhile(t *testing.T) {
+	data := []scriptTest{
+		{Script: "set x 0\nwhile {$x < 10} {incr x}\nset x", Want: "10"},
+		{Script: "set x 5\nwhile {$x < 3} {incr x}\nset x", Want: "5"},
+		{Script: "set x 0\nwhile 1 {if {[incr x] == 4} break}\nset x", Want: "4"},
+		{Script: "set x 0\nset y 0\nwhile {$x < 6} {incr x\nif {$x % 2} continue\nincr y}\nset y", Want: "3"},
+	}
+	runScripts(t, data)
+}
+
+func TestMathFunc(t *testing.T) {
+	data := []scriptTest{
+		{Script: "expr {max(-1, -2)}", Want: "-1"},
+		{Script: "expr {max(1, 5, 3)}", Want: "5"},
+		{Script: "expr {min(2, 1)}", Want: "1"},
+		{Script: "expr {min(-4, 3, -7)}", Want: "-7"},
+		{Script: "expr {min(2.5, 3)}", Want: "2.5"},
+		{Script: "expr {double(3) / 2}", Want: "1.5"},
+		{Script: "expr {double(7) == 7.0}", Want: "1"},
+		{Script: "expr {fmod(7, 3)}", Want: "1.0"},
+		{Script: "expr {fmod(-7.5, 2)}", Want: "-1.5"},
+		{Script: "tcl::mathfunc::fmod 5.5 2", Want: "1.5"},
+		{Script: "set x [expr {rand()}]\nexpr {$x > 0 && $x < 1}", Want: "1"},
+		{Script: "expr {srand(3) == srand(3)}", Want: "1"},
+		{Script: "expr {srand(42)}\nset x [expr {rand()}]\nexpr {srand(42)}\nexpr {$x == rand()}", Want: "1"},
+	}
+	runScripts(t, data)
+}
+
+func TestFrameLine(t *testing.T) {
+	data := []scriptTest{
+		{Script: "dict get [info frame 0] line", Want: "1"},
+		{Script: "set a 1\nset x [\n  dict get [info frame 0] line\n]", Want: "3"},
+		{Script: "set a 1\nset x \"a\nb [dict get [info frame 0] line]\"", Want: "a\nb 3"},
+		{Script: "set x [set y [\n\n  dict get [info frame 0] line]]", Want: "3"},
+	}
+	runScripts(t, data)
+}
+
+func TestBlankScripts(t *testing.T) {
+	data := []scriptTest{
+		{Script: "proc p {} { }\np", Want: ""},
+		{Script: "if 1 { }", Want: ""},
+		{Script: "set x 1\nif 1 {\n  \n\t\n}\nset x", Want: "1"},
+		{Script: "  \n  set x 2\n  ", Want: "2"},
+		{Script: "set c {}\ncatch {$c} msg\nset msg", Want: "invalid command name \"\""},
+		{Script: "catch {::} msg\nset msg", Want: "invalid command name \"::\""},
+	}
+	runScripts(t, data)
+}
+
+func TestQualifiedVars(t *testing.T) {
+	data := []scriptTest{
+		{Script: "set ::x 5\nset ::x", Want: "5"},
+		{Script: "set ::x 5\nset y $::x", Want: "5"},
+		{Script: "set ::x 5\nset x", Want: "5"},
+		{Script: "set x 3\nset ::x", Want: "3"},
+		{Script: "proc p {} {set ::g 7}\np\nset g", Want: "7"},
+		{Script: "set ::x 1\nunset ::x\ninfo exists x", Want: "0"},
+	}
+	runScripts(t, data)
+}
+
+func TestArrayGetSet(t *testing.T) {
+	data := []scriptTest{
+		{Script: "array set a {x 1}\narray get a", Want: "x 1"},
+		{Script: "array set a {x 1 y 2}\narray set b [array get a]\nset b(y)", Want: "2"},
+		{Script: "array set a {x 1 y 2}\nllength [array get a]", Want: "4"},
+	}
+	runScripts(t, data)
+}
+
+func TestExprWords(t *testing.T) {
+	data := []scriptTest{
+		{Script: "set a 1\nset b 1\nexpr $a eq $b", Want: "1"},
+		{Script: "expr 1 + 2 * 3", Want: "7"},
+		{Script: "expr {1 +} 2", Want: "3"},
+	}
+	runScripts(t, data)
+}
+
+func TestDictErrors(t *testing.T) {
+	data := []scriptTest{
+		{Script: "catch {dict get {a 1 b} a} msg\nset msg", Want: "missing value to go with key"},
+		{Script: "catch {dict get {a 1} zz} msg\nset msg", Want: "key \"zz\" not known in dictionary"},
+		{Script: "set d {a {b 1}}\ncatch {dict unset d x b} msg\nset msg", Want: "key \"x\" not known in dictionary"},
+		{Script: "dict exists {a 1 b} a", Want: "0"},
+	}
+	runScripts(t, data)
+}
+
+func TestCoroutineKillCatch(t *testing.T) {
+	data := []scriptTest{
+		{Script: "proc p {} {catch yield\nset ::after 1}\ncoroutine c p\nrename c {}\ninfo exists after", Want: "0"},
+		{Script: "set n 0\nproc p {} {while 1 {catch yield\nincr ::n}}\ncoroutine c p\nc\nrename c {}\nset n", Want: "1"},
+		{Script: "proc p {} {catch {catch yield}\nset ::after 1}\ncoroutine c p\nrename c {}\ninfo exists after", Want: "0"},
+	}
+	runScripts(t, data)
+}
+
+func TestScan(t *testing.T) {
+	data := []scriptTest{
+		{Script: "scan \"\" %d", Want: "-1"},
+		{Script: "scan \"  \" %d", Want: "-1"},
+		{Script: "scan \"\" %d x", Want: "-1"},
+		{Script: "scan \"\" \"%n%d\" n x", Want: "-1"},
+		{Script: "scan \"\" a%d x", Want: "-1"},
+		{Script: "scan \"12\" \"%d %d\"", Want: "12 {}"},
+		{Script: "scan \"12\" \"%d %d\" x y", Want: "1"},
+		{Script: "scan \"a\" %d x", Want: "0"},
+		{Script: "scan \"12 34\" \"%d %d\" x y\nexpr {$x + $y}", Want: "46"},
+	}
+	runScripts(t, data)
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"os/exec"
 	"sort"
+	"strings"
 
 	"github.com/midbel/gotcl/env"
 	"github.com/midbel/gotcl/stdlib"
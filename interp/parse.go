@@ -8,19 +8,210 @@ import (
 
 	"github.com/midbel/gotcl/env"
 	"github.com/midbel/gotcl/word"
+	"github.com/midbel/slices"
 )
 
 var ErrIncomplete = errors.New("incomplete")
 
+const maxScripts = 1024
+
 type Command struct {
 	Name env.Value
 	Args []env.Value
 }
 
+type script struct {
+	cmds []compiledCommand
+	err  error
+}
+
+func compile(str string) *script {
+	return compileAt(str, 0)
+}
+
+// compileAt compiles a script found inside another one, after the given
+// number of lines, so that its commands keep the lines of the enclosing
+// script.
+func compileAt(str string, offset int) *script {
+	var s script
+	p, err := New(strings.NewReader(str))
+	if err != nil {
+		s.err = err
+		return &s
+	}
+	p.offset = offset
+	for {
+		c, err := p.Parse()
+		if err != nil {
+			if !errors.Is(err, io.EOF) {
+				s.err = err
+			}
+			break
+		}
+		s.cmds = append(s.cmds, c)
+	}
+	return &s
+}
+
+type compiledCommand struct {
+	words []compiledWord
+	line  int
+	text  string
+}
+
+func (c compiledCommand) substitute(i *Interpreter) (*Command, error) {
+	var cmd Command
+	for _, w := range c.words {
+		v, err := w.substitute(i)
+		if err != nil {
+			return nil, err
+		}
+		if !w.expand {
+			cmd.Args = append(cmd.Args, v)
+			continue
+		}
+		list, err := v.ToList()
+		if err != nil {
+			return nil, err
+		}
+		cmd.Args = append(cmd.Args, list.(env.List).Values()...)
+	}
+	if len(cmd.Args) == 0 {
+		return nil, nil
+	}
+	cmd.Name, cmd.Args = slices.Fst(cmd.Args), slices.Rest(cmd.Args)
+	return &cmd, nil
+}
+
+type compiledWord struct {
+	value  env.Value
+	tokens []token
+	expand bool
+}
+
+func (w compiledWord) substitute(i *Interpreter) (env.Value, error) {
+	if w.value != nil {
+		return w.value, nil
+	}
+	return substituteTokens(w.tokens, i)
+}
+
+type token struct {
+	word.Word
+	parts  []token
+	script *script
+}
+
+func compileToken(w word.Word, offset int) (token, error) {
+	t := token{
+		Word: w,
+	}
+	if w.Line > 0 {
+		offset += w.Line - 1
+	}
+	switch w.Type {
+	case word.Literal, word.Block:
+	case word.Variable:
+		_, key, ok := word.SplitIndex(w.Literal)
+		if !ok || !strings.ContainsAny(key, "$[") {
+			break
+		}
+		parts, err := compileParts(key, offset)
+		if err != nil {
+			return t, err
+		}
+		t.parts = parts
+	case word.Quote:
+		parts, err := compileParts(w.Literal, offset)
+		if err != nil {
+			return t, err
+		}
+		t.parts = parts
+	case word.Script:
+		t.script = compileAt(w.Literal, offset)
+	default:
+		return t, fmt.Errorf("%s: %w", w, ErrSyntax)
+	}
+	return t, nil
+}
+
+func compileParts(str string, offset int) ([]token, error) {
+	return compileSubst(str, word.SubstAll, offset)
+}
+
+func compileSubst(str string, flags, offset int) ([]token, error) {
+	list, err := word.Subst(str, flags)
+	if err != nil {
+		return nil, err
+	}
+	var parts []token
+	for _, w := range list {
+		p, err := compileToken(w, offset)
+		if err != nil {
+			return nil, err
+		}
+		parts = append(parts, p)
+	}
+	return parts, nil
+}
+
+func (t token) constant() bool {
+	switch t.Type {
+	case word.Literal, word.Block:
+		return true
+	case word.Quote:
+		for _, p := range t.parts {
+			if !p.constant() {
+				return false
+			}
+		}
+		return true
+	default:
+		return false
+	}
+}
+
+func (t token) substitute(i *Interpreter) (env.Value, error) {
+	switch t.Type {
+	case word.Literal, word.Block:
+		return env.Str(t.Literal), nil
+	case word.Variable:
+		if len(t.parts) == 0 {
+			return i.Resolve(t.Literal)
+		}
+		key, err := substituteTokens(t.parts, i)
+		if err != nil {
+			return nil, err
+		}
+		name, _, _ := word.SplitIndex(t.Literal)
+		return i.Resolve(fmt.Sprintf("%s(%s)", name, key))
+	case word.Quote:
+		return substituteTokens(t.parts, i)
+	case word.Script:
+		return i.run(t.script)
+	default:
+		return nil, fmt.Errorf("%s: %w", t.Word, ErrSyntax)
+	}
+}
+
+func substituteTokens(list []token, i *Interpreter) (env.Value, error) {
+	var vs []env.Value
+	for _, t := range list {
+		v, err := t.substitute(i)
+		if err != nil {
+			return nil, err
+		}
+		vs = append(vs, v)
+	}
+	return list2str(vs), nil
+}
+
 type Parser struct {
 	scan *word.Scanner
 	curr word.Word
 	peek word.Word
+
+	offset int
 }
 
 func New(r io.Reader) (*Parser, error) {
@@ -36,48 +227,61 @@ func New(r io.Reader) (*Parser, error) {
 	return &p, nil
 }
 
-func (p *Parser) Parse(i *Interpreter) (*Command, error) {
-	p.skipEmptyLines()
-	if p.done() {
-		return nil, io.EOF
+func (p *Parser) Parse() (compiledCommand, error) {
+	for p.isEnd() && !p.done() {
+		p.skipEmptyLines()
+		p.skipBlank()
 	}
-	var (
-		c   Command
-		err error
-	)
-	c.Name, err = p.parse(i)
-	if err != nil {
-		return nil, err
+	var c compiledCommand
+	if p.done() {
+		return c, io.EOF
 	}
-	for !p.done() && !p.curr.IsEOL() {
-		arg, err := p.parse(i)
+	pos := p.curr.Position
+	for {
+		w, err := p.parse()
 		if err != nil {
-			return nil, err
+			return c, err
+		}
+		c.words = append(c.words, w)
+		if p.done() || p.curr.IsEOL() {
+			break
 		}
-		c.Args = append(c.Args, arg)
 	}
+	c.line = pos.Line + p.offset
+	c.text = strings.TrimSpace(p.scan.Text(pos.Offset, p.curr.Offset))
 	p.next()
-	return &c, nil
+	return c, nil
 }
 
-func (p *Parser) parse(i *Interpreter) (env.Value, error) {
+func (p *Parser) parse() (compiledWord, error) {
 	p.skipBlank()
-	var vs []env.Value
+	var w compiledWord
+	if p.curr.Type == word.Expand {
+		w.expand = true
+		p.next()
+	}
 	for !p.isEnd() {
 		if p.curr.Type == word.Illegal {
-			return nil, ErrSyntax
+			return w, ErrSyntax
 		}
-		v, err := substitute(p.curr, i)
+		t, err := compileToken(p.curr, p.offset)
 		if err != nil {
-			return nil, err
+			return w, err
 		}
-		vs = append(vs, v)
+		w.tokens = append(w.tokens, t)
 		p.next()
 	}
 	if p.isBlank() {
 		p.next()
 	}
-	return list2str(vs), nil
+	constant := true
+	for _, t := range w.tokens {
+		constant = constant && t.constant()
+	}
+	if constant {
+		w.value, _ = substituteTokens(w.tokens, nil)
+	}
+	return w, nil
 }
 
 func (p *Parser) next() {
@@ -125,42 +329,3 @@ func list2str(list []env.Value) env.Value {
 	}
 	return env.Str(str.String())
 }
-
-func substitute(curr word.Word, i *Interpreter) (env.Value, error) {
-	split := func(str string, i *Interpreter) (env.Value, error) {
-		scan, err := word.Scan(strings.NewReader(str))
-		if err != nil {
-			return nil, err
-		}
-		var list []env.Value
-		for {
-			w := scan.Split()
-			if w.Type == word.EOF {
-				break
-			}
-			val, err := substitute(w, i)
-			if err != nil {
-				return nil, err
-			}
-			list = append(list, val)
-		}
-		return list2str(list), nil
-	}
-	var (
-		val env.Value
-		err error
-	)
-	switch curr.Type {
-	case word.Literal, word.Block:
-		val = env.Str(curr.Literal)
-	case word.Variable:
-		val, err = i.Resolve(curr.Literal)
-	case word.Quote:
-		val, err = split(curr.Literal, i)
-	case word.Script:
-		val, err = i.Execute(strings.NewReader(curr.Literal))
-	default:
-		err = fmt.Errorf("%s: %w", curr, ErrSyntax)
-	}
-	return val, err
-}
@@ -0,0 +1,181 @@
+package interp
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net"
+
+	"github.com/midbel/gotcl/env"
+	"github.com/midbel/gotcl/stdlib"
+)
+
+type asyncConn struct {
+	conn net.Conn
+	err  error
+	done chan struct{}
+}
+
+func dialAsync(d net.Dialer, addr string) *asyncConn {
+	c := asyncConn{
+		done: make(chan struct{}),
+	}
+	go func() {
+		defer close(c.done)
+		c.conn, c.err = d.Dial("tcp", addr)
+	}()
+	return &c
+}
+
+func (c *asyncConn) Read(b []byte) (int, error) {
+	if err := c.wait(); err != nil {
+		return 0, err
+	}
+	return c.conn.Read(b)
+}
+
+func (c *asyncConn) Write(b []byte) (int, error) {
+	if err := c.wait(); err != nil {
+		return 0, err
+	}
+	return c.conn.Write(b)
+}
+
+func (c *asyncConn) CloseRead() error {
+	if err := c.wait(); err != nil {
+		return err
+	}
+	h, ok := c.conn.(interface{ CloseRead() error })
+	if !ok {
+		return fmt.Errorf("connection can not be half closed")
+	}
+	return h.CloseRead()
+}
+
+func (c *asyncConn) CloseWrite() error {
+	if err := c.wait(); err != nil {
+		return err
+	}
+	h, ok := c.conn.(interface{ CloseWrite() error })
+	if !ok {
+		return fmt.Errorf("connection can not be half closed")
+	}
+	return h.CloseWrite()
+}
+
+func (c *asyncConn) Close() error {
+	if err := c.wait(); err != nil {
+		return nil
+	}
+	return c.conn.Close()
+}
+
+func (c *asyncConn) wait() error {
+	<-c.done
+	if c.err != nil {
+		return fmt.Errorf("couldn't open socket: %w", c.err)
+	}
+	return nil
+}
+
+type server struct {
+	listener net.Listener
+	pending  net.Conn
+	err      error
+}
+
+func (s *server) Read(_ []byte) (int, error) {
+	return 0, fmt.Errorf("can't read from a server socket")
+}
+
+func (s *server) Write(_ []byte) (int, error) {
+	return 0, fmt.Errorf("can't write to a server socket")
+}
+
+func (s *server) Close() error {
+	return s.listener.Close()
+}
+
+func (s *server) poll(_ <-chan struct{}) {
+	if s.pending == nil && s.err == nil {
+		s.pending, s.err = s.listener.Accept()
+	}
+}
+
+func (s *server) accept() (net.Conn, error) {
+	conn := s.pending
+	s.pending = nil
+	if conn == nil {
+		return nil, s.err
+	}
+	return conn, nil
+}
+
+func (i *Interpreter) Dial(host, port, myaddr, myport string, async bool) (string, error) {
+	var d net.Dialer
+	if myaddr != "" || myport != "" {
+		addr, err := net.ResolveTCPAddr("tcp", net.JoinHostPort(myaddr, myport))
+		if err != nil {
+			return "", err
+		}
+		d.LocalAddr = addr
+	}
+	var (
+		addr = net.JoinHostPort(host, port)
+		rw   io.ReadWriteCloser
+	)
+	if async {
+		rw = dialAsync(d, addr)
+	} else {
+		conn, err := d.Dial("tcp", addr)
+		if err != nil {
+			return "", fmt.Errorf("couldn't open socket: %w", err)
+		}
+		rw = conn
+	}
+	return i.attach(sockprefix, newChannel(rw, true, true)), nil
+}
+
+func (i *Interpreter) Listen(myaddr, port, script string) (string, error) {
+	ln, err := net.Listen("tcp", net.JoinHostPort(myaddr, port))
+	if err != nil {
+		return "", fmt.Errorf("couldn't open socket: %w", err)
+	}
+	var (
+		srv = &server{
+			listener: ln,
+		}
+		fd = i.attach(sockprefix, newChannel(srv, false, false))
+		w  = watcher{
+			fd:     fd,
+			kind:   eventReadable,
+			source: srv,
+			arm:    make(chan struct{}, 1),
+			stop:   make(chan struct{}),
+		}
+	)
+	w.handler = func() error {
+		return i.acceptConn(srv, script)
+	}
+	go w.watch(i.events.ready)
+	i.events.watchers[fd+eventReadable] = &w
+	return fd, nil
+}
+
+func (i *Interpreter) acceptConn(srv *server, script string) error {
+	conn, err := srv.accept()
+	if err != nil {
+		i.Println(stderr, err.Error())
+		return err
+	}
+	var (
+		fd            = i.attach(sockprefix, newChannel(conn, true, true))
+		host, port, _ = net.SplitHostPort(conn.RemoteAddr().String())
+		args          = env.ListFromStrings([]string{fd, host, port})
+	)
+	err = i.executeEvent(script + " " + args.String())
+	if errors.Is(err, stdlib.ErrExit) {
+		return err
+	}
+	return nil
+}
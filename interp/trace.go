@@ -0,0 +1,356 @@
+package interp
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/midbel/gotcl/env"
+	"github.com/midbel/gotcl/stdlib"
+	"github.com/midbel/gotcl/word"
+	"github.com/midbel/slices"
+)
+
+type tracedCommand struct {
+	stdlib.Executer
+	name     string
+	commands []env.Trace
+	execs    []env.Trace
+}
+
+func unwrapCommand(exec stdlib.Executer) stdlib.Executer {
+	if t, ok := exec.(*tracedCommand); ok {
+		return t.Executer
+	}
+	return exec
+}
+
+func (c *tracedCommand) Execute(i stdlib.Interpreter, args []env.Value) (env.Value, error) {
+	x, ok := i.(*Interpreter)
+	if !ok || x.tracing || len(c.execs) == 0 {
+		return c.Executer.Execute(i, args)
+	}
+	line := env.ListFrom(slices.Prepend(env.Str(strings.TrimPrefix(c.name, "::")), args)...)
+	if err := x.runTraces(c.execs, "enter", line); err != nil {
+		return nil, err
+	}
+	for _, t := range c.execs {
+		if t.Has("enterstep") || t.Has("leavestep") {
+			x.steps = append(x.steps, c)
+			defer func() {
+				x.steps = x.steps[:len(x.steps)-1]
+			}()
+			break
+		}
+	}
+	res, err := c.Executer.Execute(i, args)
+	if err := x.runTraces(c.execs, "leave", line, env.Int(int64(returnCode(err))), resultOf(res, err)); err != nil {
+		return nil, err
+	}
+	return res, err
+}
+
+func (c *tracedCommand) empty() bool {
+	return len(c.commands) == 0 && len(c.execs) == 0
+}
+
+func returnCode(err error) int {
+	switch {
+	case err == nil:
+		return stdlib.ErrorOk
+	case errors.Is(err, stdlib.ErrReturn):
+		return stdlib.ErrorRet
+	case errors.Is(err, stdlib.ErrBreak):
+		return stdlib.ErrorBreak
+	case errors.Is(err, stdlib.ErrContinue):
+		return stdlib.ErrorContinue
+	default:
+		return stdlib.ErrorErr
+	}
+}
+
+func resultOf(res env.Value, err error) env.Value {
+	if err != nil {
+		return env.Str(err.Error())
+	}
+	if res == nil {
+		return env.EmptyStr()
+	}
+	return res
+}
+
+func (i *Interpreter) TraceAdd(kind, name string, ops []string, cmd env.Value) error {
+	t := env.Trace{
+		Ops: ops,
+		Cmd: cmd,
+	}
+	if kind == "variable" {
+		e, n := i.locate(name)
+		if e == nil {
+			return fmt.Errorf("%s: %w", name, ErrUndefined)
+		}
+		e.AddTrace(n, t)
+		i.vtraces++
+		return nil
+	}
+	ns, n, err := i.lookupCommand(name)
+	if err != nil {
+		return err
+	}
+	c, ok := ns.CommandSet[n].(*tracedCommand)
+	if !ok {
+		c = &tracedCommand{
+			Executer: ns.CommandSet[n],
+			name:     qualifiedName(ns, n),
+		}
+		ns.CommandSet[n] = c
+	}
+	switch kind {
+	case "command":
+		c.commands = append(c.commands, t)
+	case "execution":
+		c.execs = append(c.execs, t)
+	default:
+		return fmt.Errorf("%s: unknown trace type", kind)
+	}
+	return nil
+}
+
+func (i *Interpreter) TraceRemove(kind, name string, ops []string, cmd env.Value) error {
+	if kind == "variable" {
+		e, n := i.locate(name)
+		if e != nil && e.RemoveTrace(n, ops, cmd) {
+			i.vtraces--
+		}
+		return nil
+	}
+	ns, n, err := i.lookupCommand(name)
+	if err != nil {
+		return err
+	}
+	c, ok := ns.CommandSet[n].(*tracedCommand)
+	if !ok {
+		return nil
+	}
+	switch kind {
+	case "command":
+		c.commands = removeTrace(c.commands, ops, cmd)
+	case "execution":
+		c.execs = removeTrace(c.execs, ops, cmd)
+	default:
+		return fmt.Errorf("%s: unknown trace type", kind)
+	}
+	if c.empty() {
+		ns.CommandSet[n] = c.Executer
+	}
+	return nil
+}
+
+func (i *Interpreter) TraceInfo(kind, name string) ([]env.Value, error) {
+	var list []env.Trace
+	if kind == "variable" {
+		if e, n := i.locate(name); e != nil {
+			list = e.Traces(n)
+		}
+	} else {
+		ns, n, err := i.lookupCommand(name)
+		if err != nil {
+			return nil, err
+		}
+		if c, ok := ns.CommandSet[n].(*tracedCommand); ok {
+			switch kind {
+			case "command":
+				list = c.commands
+			case "execution":
+				list = c.execs
+			default:
+				return nil, fmt.Errorf("%s: unknown trace type", kind)
+			}
+		}
+	}
+	var vs []env.Value
+	for _, t := range list {
+		vs = append(vs, env.ListFrom(env.ListFromStrings(t.Ops), t.Cmd))
+	}
+	return vs, nil
+}
+
+func (i *Interpreter) TraceArray(name string) error {
+	return i.traceVar(name, "array")
+}
+
+func (i *Interpreter) traceVar(name, op string) error {
+	if i.vtraces == 0 || i.tracing {
+		return nil
+	}
+	base, key, elem := word.SplitIndex(name)
+	e, n := i.locate(base)
+	if e == nil {
+		return nil
+	}
+	list := e.Traces(n)
+	if elem {
+		list = append(list[:len(list):len(list)], e.Traces(fmt.Sprintf("%s(%s)", n, key))...)
+	} else if op == "unset" {
+		i.vtraces -= e.ClearTraces(n)
+	}
+	for _, t := range list {
+		if !t.Has(op) {
+			continue
+		}
+		err := i.runTrace(t.Cmd, env.Str(base), env.Str(key), env.Str(op))
+		if err != nil && op != "unset" {
+			if op == "write" {
+				op = "set"
+			}
+			return fmt.Errorf("can't %s %q: %w", op, name, err)
+		}
+	}
+	return nil
+}
+
+func (i *Interpreter) traceCommand(exec stdlib.Executer, prev, next string) {
+	c, ok := exec.(*tracedCommand)
+	if !ok {
+		return
+	}
+	op := "rename"
+	if next == "" {
+		op = "delete"
+	} else {
+		next = qualifiedName(i.currentNS(), next)
+		c.name = next
+	}
+	for _, t := range c.commands {
+		if !t.Has(op) {
+			continue
+		}
+		i.runTrace(t.Cmd, env.Str(prev), env.Str(next), env.Str(op))
+	}
+	if op == "delete" {
+		c.commands = nil
+	}
+}
+
+func (i *Interpreter) executeStep(exec stdlib.Executer, c *Command) (env.Value, error) {
+	line := env.ListFrom(slices.Prepend(c.Name, c.Args)...)
+	steps := slices.Lst(i.steps)
+	if err := i.runTraces(steps.execs, "enterstep", line); err != nil {
+		return nil, err
+	}
+	res, err := exec.Execute(i, c.Args)
+	if err := i.runTraces(steps.execs, "leavestep", line, env.Int(int64(returnCode(err))), resultOf(res, err)); err != nil {
+		return nil, err
+	}
+	return res, err
+}
+
+func (i *Interpreter) runTraces(list []env.Trace, op string, args ...env.Value) error {
+	for _, t := range list {
+		if !t.Has(op) {
+			continue
+		}
+		if err := i.runTrace(t.Cmd, append(args, env.Str(op))...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (i *Interpreter) runTrace(cmd env.Value, args ...env.Value) error {
+	list, err := env.ToStringList(cmd)
+	if err != nil {
+		return err
+	}
+	if len(list) == 0 {
+		return nil
+	}
+	var vs []env.Value
+	for _, s := range slices.Rest(list) {
+		vs = append(vs, env.Str(s))
+	}
+	i.tracing = true
+	defer func() {
+		i.tracing = false
+	}()
+	_, err = i.execute(&Command{
+		Name: env.Str(slices.Fst(list)),
+		Args: append(vs, args...),
+	})
+	return err
+}
+
+func (i *Interpreter) locate(name string) (*env.Env, string) {
+	if base, key, ok := word.SplitIndex(name); ok {
+		e, n := i.locate(base)
+		return e, fmt.Sprintf("%s(%s)", n, key)
+	}
+	parts := strings.Split(name, "::")
+	if len(parts) > 1 {
+		var (
+			ns  = i.currentNS()
+			err error
+		)
+		if slices.Fst(parts) == "" {
+			ns, parts = i.rootNS(), slices.Rest(parts)
+		}
+		if ns, err = ns.LookupNS(slices.Slice(parts)); err != nil {
+			return nil, ""
+		}
+		return ns.env, slices.Lst(parts)
+	}
+	f := i.currentFrame()
+	if v, err := f.env.Resolve(name); err == nil {
+		if k, ok := v.(env.Link); ok {
+			return i.frames[k.At()].env, k.String()
+		}
+		return f.env, name
+	}
+	if _, err := f.ns.env.Resolve(name); err == nil {
+		return f.ns.env, name
+	}
+	return f.env, name
+}
+
+func (i *Interpreter) lookupCommand(name string) (*Namespace, string, error) {
+	var (
+		parts = strings.Split(name, "::")
+		ns    = i.currentNS()
+		err   error
+	)
+	if slices.Fst(parts) == "" {
+		ns, parts = i.rootNS(), slices.Rest(parts)
+	}
+	if len(parts) > 1 {
+		if ns, err = ns.LookupNS(slices.Slice(parts)); err != nil {
+			return nil, "", err
+		}
+	}
+	n := slices.Lst(parts)
+	for ns != nil {
+		if _, ok := ns.CommandSet[n]; ok {
+			return ns, n, nil
+		}
+		if len(parts) > 1 {
+			break
+		}
+		ns = ns.parent
+	}
+	return nil, "", undefinedProc(name)
+}
+
+func qualifiedName(ns *Namespace, name string) string {
+	if ns.Root() {
+		return "::" + name
+	}
+	return ns.FQN() + "::" + name
+}
+
+func removeTrace(list []env.Trace, ops []string, cmd env.Value) []env.Trace {
+	for j, t := range list {
+		if t.Equal(ops, cmd) {
+			return append(list[:j:j], list[j+1:]...)
+		}
+	}
+	return list
+}
@@ -17,33 +17,42 @@ func MakeArray() Executer {
 			Builtin{
 				Name:  "set",
 				Arity: 2,
-				Run:   arraySet,
+				Run:   wrapArrayFunc(arraySet),
 			},
 			Builtin{
 				Name:  "unset",
 				Arity: 2,
-				Run:   arrayUnset,
+				Run:   wrapArrayFunc(arrayUnset),
 			},
 			Builtin{
 				Name:  "get",
 				Arity: 1,
-				Run:   arrayGet,
+				Run:   wrapArrayFunc(arrayGet),
 			},
 			Builtin{
 				Name:  "names",
 				Arity: 1,
-				Run:   arrayNames,
+				Run:   wrapArrayFunc(arrayNames),
 			},
 			Builtin{
 				Name:  "size",
 				Arity: 1,
-				Run:   arraySize,
+				Run:   wrapArrayFunc(arraySize),
 			},
 		},
 	}
 	return sortEnsembleCommands(e)
 }
 
+func wrapArrayFunc(do CommandFunc) CommandFunc {
+	return func(i Interpreter, args []env.Value) (env.Value, error) {
+		if err := traceArray(i, slices.Fst(args).String()); err != nil {
+			return nil, err
+		}
+		return do(i, args)
+	}
+}
+
 func PrintArray() Executer {
 	return Builtin{
 		Name:  "parray",
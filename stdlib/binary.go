@@ -0,0 +1,790 @@
+package stdlib
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math"
+	"runtime"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/midbel/gotcl/env"
+	"github.com/midbel/gotcl/stdlib/encoding"
+	"github.com/midbel/slices"
+)
+
+var ErrBinary = errors.New("invalid binary field specifier")
+
+func MakeBinary() Executer {
+	e := Ensemble{
+		Name: "binary",
+		Help: "insert and extract fields from binary strings",
+		Safe: true,
+		List: []Executer{
+			Builtin{
+				Name:     "format",
+				Arity:    1,
+				Variadic: true,
+				Run:      binaryFormat,
+			},
+			Builtin{
+				Name:     "scan",
+				Arity:    2,
+				Variadic: true,
+				Run:      binaryScan,
+			},
+			makeBinaryEncode(),
+			makeBinaryDecode(),
+		},
+	}
+	return sortEnsembleCommands(e)
+}
+
+func makeBinaryEncode() Executer {
+	options := func(maxlen int) []Option {
+		return []Option{
+			{
+				Name:  "maxlen",
+				Value: env.Int(int64(maxlen)),
+				Check: CheckNumber,
+			},
+			{
+				Name:  "wrapchar",
+				Value: env.Str("\n"),
+				Check: CheckString,
+			},
+		}
+	}
+	e := Ensemble{
+		Name: "encode",
+		List: []Executer{
+			Builtin{
+				Name:    "base64",
+				Arity:   1,
+				Options: options(0),
+				Run:     binaryEncodeBase64,
+			},
+			Builtin{
+				Name:  "hex",
+				Arity: 1,
+				Run:   binaryEncodeHex,
+			},
+			Builtin{
+				Name:    "uuencode",
+				Arity:   1,
+				Options: options(61),
+				Run:     binaryEncodeUu,
+			},
+		},
+	}
+	return sortEnsembleCommands(e)
+}
+
+func makeBinaryDecode() Executer {
+	options := func() []Option {
+		return []Option{
+			{
+				Name:  "strict",
+				Flag:  true,
+				Value: env.False(),
+				Check: CheckBool,
+			},
+		}
+	}
+	e := Ensemble{
+		Name: "decode",
+		List: []Executer{
+			Builtin{
+				Name:    "base64",
+				Arity:   1,
+				Options: options(),
+				Run:     binaryDecodeBase64,
+			},
+			Builtin{
+				Name:    "hex",
+				Arity:   1,
+				Options: options(),
+				Run:     binaryDecodeHex,
+			},
+			Builtin{
+				Name:    "uuencode",
+				Arity:   1,
+				Options: options(),
+				Run:     binaryDecodeUu,
+			},
+		},
+	}
+	return sortEnsembleCommands(e)
+}
+
+type binaryField struct {
+	kind     byte
+	unsigned bool
+	count    int
+	star     bool
+	counted  bool
+}
+
+func (f binaryField) size() int {
+	switch f.kind {
+	case 'c':
+		return 1
+	case 's', 'S', 't':
+		return 2
+	case 'i', 'I', 'n', 'f', 'r', 'R':
+		return 4
+	case 'w', 'W', 'm', 'd', 'q', 'Q':
+		return 8
+	default:
+		return 0
+	}
+}
+
+func (f binaryField) order() binary.ByteOrder {
+	switch f.kind {
+	case 's', 'i', 'w', 'r', 'q':
+		return binary.LittleEndian
+	case 'S', 'I', 'W', 'R', 'Q':
+		return binary.BigEndian
+	default:
+		return nativeOrder
+	}
+}
+
+func (f binaryField) float() bool {
+	return strings.IndexByte("frRdqQ", f.kind) >= 0
+}
+
+var nativeOrder binary.ByteOrder = binary.LittleEndian
+
+func init() {
+	switch runtime.GOARCH {
+	case "mips", "mips64", "ppc64", "s390x", "sparc64":
+		nativeOrder = binary.BigEndian
+	default:
+	}
+}
+
+func parseBinaryFields(str string) ([]binaryField, error) {
+	var list []binaryField
+	for j := 0; j < len(str); {
+		if c := str[j]; c == ' ' || c == '\t' || c == '\n' || c == '\r' {
+			j++
+			continue
+		}
+		f := binaryField{
+			kind: str[j],
+		}
+		if strings.IndexByte("aAbBhHcsStiInwWmfrRdqQxX@", f.kind) < 0 {
+			return nil, fmt.Errorf("bad field specifier %q", str[j:j+1])
+		}
+		j++
+		if j < len(str) && str[j] == 'u' {
+			f.unsigned = true
+			j++
+		}
+		switch {
+		case j < len(str) && str[j] == '*':
+			f.star, f.counted = true, true
+			j++
+		case j < len(str) && isDigit(str[j]):
+			k := j
+			for j < len(str) && isDigit(str[j]) {
+				j++
+			}
+			n, err := strconv.Atoi(str[k:j])
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", str[k:j], ErrBinary)
+			}
+			f.count, f.counted = n, true
+		default:
+			f.count = 1
+		}
+		list = append(list, f)
+	}
+	return list, nil
+}
+
+func binaryFormat(i Interpreter, args []env.Value) (env.Value, error) {
+	fields, err := parseBinaryFields(slices.Fst(args).String())
+	if err != nil {
+		return nil, err
+	}
+	var (
+		buf    []byte
+		cursor int
+		values = slices.Rest(args)
+	)
+	put := func(b []byte) {
+		for _, c := range b {
+			if cursor < len(buf) {
+				buf[cursor] = c
+			} else {
+				buf = append(buf, c)
+			}
+			cursor++
+		}
+	}
+	for _, f := range fields {
+		switch f.kind {
+		case 'x':
+			if f.star {
+				return nil, fmt.Errorf("cannot use \"*\" in format string with \"x\"")
+			}
+			put(make([]byte, f.count))
+			continue
+		case 'X':
+			if f.star || f.count > cursor {
+				cursor = 0
+			} else {
+				cursor -= f.count
+			}
+			continue
+		case '@':
+			if !f.counted {
+				return nil, fmt.Errorf("missing count for \"@\" field specifier")
+			}
+			if f.star {
+				cursor = len(buf)
+				continue
+			}
+			if f.count > len(buf) {
+				buf = append(buf, make([]byte, f.count-len(buf))...)
+			}
+			cursor = f.count
+			continue
+		default:
+		}
+		if len(values) == 0 {
+			return nil, fmt.Errorf("not enough arguments for all format specifiers")
+		}
+		var (
+			val = slices.Fst(values)
+			out []byte
+		)
+		values = slices.Rest(values)
+		switch f.kind {
+		case 'a', 'A':
+			out = formatBinaryString(f, val.String())
+		case 'b', 'B':
+			out, err = formatBinaryBits(f, val.String())
+		case 'h', 'H':
+			out, err = formatBinaryHex(f, val.String())
+		default:
+			out, err = formatBinaryNumbers(f, val)
+		}
+		if err != nil {
+			return nil, err
+		}
+		put(out)
+	}
+	if len(values) > 0 {
+		return nil, fmt.Errorf("too many arguments for all format specifiers")
+	}
+	return env.Str(fromBytes(buf)), nil
+}
+
+func formatBinaryString(f binaryField, str string) []byte {
+	b := toBytes(str)
+	if f.star {
+		return b
+	}
+	out := make([]byte, f.count)
+	if f.kind == 'A' {
+		for j := range out {
+			out[j] = ' '
+		}
+	}
+	copy(out, b)
+	return out
+}
+
+func formatBinaryBits(f binaryField, str string) ([]byte, error) {
+	count := f.count
+	if f.star {
+		count = len(str)
+	}
+	out := make([]byte, (count+7)/8)
+	for j := 0; j < count && j < len(str); j++ {
+		switch str[j] {
+		case '0':
+			continue
+		case '1':
+		default:
+			return nil, fmt.Errorf("expected binary string but got %q instead", str)
+		}
+		if f.kind == 'b' {
+			out[j/8] |= 1 << (j % 8)
+		} else {
+			out[j/8] |= 0x80 >> (j % 8)
+		}
+	}
+	return out, nil
+}
+
+func formatBinaryHex(f binaryField, str string) ([]byte, error) {
+	count := f.count
+	if f.star {
+		count = len(str)
+	}
+	out := make([]byte, (count+1)/2)
+	for j := 0; j < count && j < len(str); j++ {
+		n, err := strconv.ParseUint(str[j:j+1], 16, 8)
+		if err != nil {
+			return nil, fmt.Errorf("expected hexadecimal string but got %q instead", str)
+		}
+		if (j%2 == 0) == (f.kind == 'h') {
+			out[j/2] |= byte(n)
+		} else {
+			out[j/2] |= byte(n) << 4
+		}
+	}
+	return out, nil
+}
+
+func formatBinaryNumbers(f binaryField, val env.Value) ([]byte, error) {
+	list := []env.Value{val}
+	if f.counted {
+		vs, err := listValues(val)
+		if err != nil {
+			return nil, err
+		}
+		list = vs
+		if !f.star {
+			if f.count > len(list) {
+				return nil, fmt.Errorf("number of elements in list does not match count")
+			}
+			list = list[:f.count]
+		}
+	}
+	var (
+		size  = f.size()
+		order = f.order()
+		out   = make([]byte, size*len(list))
+	)
+	for j, v := range list {
+		b := out[j*size:]
+		if f.float() {
+			x, err := env.ToFloat(v)
+			if err != nil {
+				return nil, fmt.Errorf("expected floating-point number but got %q", v.String())
+			}
+			if size == 4 {
+				order.PutUint32(b, math.Float32bits(float32(x)))
+			} else {
+				order.PutUint64(b, math.Float64bits(x))
+			}
+			continue
+		}
+		x, err := toInteger(v)
+		if err != nil {
+			return nil, err
+		}
+		switch size {
+		case 1:
+			b[0] = byte(x)
+		case 2:
+			order.PutUint16(b, uint16(x))
+		case 4:
+			order.PutUint32(b, uint32(x))
+		default:
+			order.PutUint64(b, uint64(x))
+		}
+	}
+	return out, nil
+}
+
+func binaryScan(i Interpreter, args []env.Value) (env.Value, error) {
+	var (
+		data   = toBytes(slices.Fst(args).String())
+		names  = slices.Take(args, 2)
+		cursor int
+		count  int
+	)
+	fields, err := parseBinaryFields(slices.Snd(args).String())
+	if err != nil {
+		return nil, err
+	}
+	for _, f := range fields {
+		switch f.kind {
+		case 'x':
+			if f.star || cursor+f.count > len(data) {
+				cursor = len(data)
+			} else {
+				cursor += f.count
+			}
+			continue
+		case 'X':
+			if f.star || f.count > cursor {
+				cursor = 0
+			} else {
+				cursor -= f.count
+			}
+			continue
+		case '@':
+			if !f.counted {
+				return nil, fmt.Errorf("missing count for \"@\" field specifier")
+			}
+			if f.star || f.count > len(data) {
+				cursor = len(data)
+			} else {
+				cursor = f.count
+			}
+			continue
+		default:
+		}
+		if len(names) == 0 {
+			return nil, fmt.Errorf("not enough arguments for all format specifiers")
+		}
+		var (
+			name = slices.Fst(names)
+			rest = data[cursor:]
+			val  env.Value
+			n    int
+		)
+		names = slices.Rest(names)
+		switch f.kind {
+		case 'a', 'A':
+			val, n = scanBinaryString(f, rest)
+		case 'b', 'B':
+			val, n = scanBinaryBits(f, rest)
+		case 'h', 'H':
+			val, n = scanBinaryHex(f, rest)
+		default:
+			val, n = scanBinaryNumbers(f, rest)
+		}
+		if val == nil {
+			break
+		}
+		i.Define(name.String(), val)
+		cursor += n
+		count++
+	}
+	return env.Int(int64(count)), nil
+}
+
+func scanBinaryString(f binaryField, data []byte) (env.Value, int) {
+	n := f.count
+	if f.star {
+		n = len(data)
+	}
+	if n > len(data) {
+		return nil, 0
+	}
+	str := fromBytes(data[:n])
+	if f.kind == 'A' {
+		str = strings.TrimRight(str, " \x00")
+	}
+	return env.Str(str), n
+}
+
+func scanBinaryBits(f binaryField, data []byte) (env.Value, int) {
+	count := f.count
+	if f.star {
+		count = len(data) * 8
+	}
+	n := (count + 7) / 8
+	if n > len(data) {
+		return nil, 0
+	}
+	var str strings.Builder
+	for j := 0; j < count; j++ {
+		var set bool
+		if f.kind == 'b' {
+			set = data[j/8]&(1<<(j%8)) != 0
+		} else {
+			set = data[j/8]&(0x80>>(j%8)) != 0
+		}
+		if set {
+			str.WriteByte('1')
+		} else {
+			str.WriteByte('0')
+		}
+	}
+	return env.Str(str.String()), n
+}
+
+func scanBinaryHex(f binaryField, data []byte) (env.Value, int) {
+	count := f.count
+	if f.star {
+		count = len(data) * 2
+	}
+	n := (count + 1) / 2
+	if n > len(data) {
+		return nil, 0
+	}
+	var (
+		digits = "0123456789abcdef"
+		str    strings.Builder
+	)
+	for j := 0; j < count; j++ {
+		c := data[j/2]
+		if (j%2 == 0) == (f.kind == 'h') {
+			c &= 0x0F
+		} else {
+			c >>= 4
+		}
+		str.WriteByte(digits[c])
+	}
+	return env.Str(str.String()), n
+}
+
+func scanBinaryNumbers(f binaryField, data []byte) (env.Value, int) {
+	var (
+		size  = f.size()
+		count = 1
+	)
+	switch {
+	case f.star:
+		count = len(data) / size
+	case f.counted:
+		count = f.count
+	}
+	if count*size > len(data) {
+		return nil, 0
+	}
+	list := make([]env.Value, 0, count)
+	for j := 0; j < count; j++ {
+		list = append(list, scanBinaryNumber(f, data[j*size:]))
+	}
+	if !f.counted {
+		return slices.Fst(list), size
+	}
+	return env.ListFrom(list...), count * size
+}
+
+func scanBinaryNumber(f binaryField, b []byte) env.Value {
+	order := f.order()
+	if f.float() {
+		if f.size() == 4 {
+			return env.Float(float64(math.Float32frombits(order.Uint32(b))))
+		}
+		return env.Float(math.Float64frombits(order.Uint64(b)))
+	}
+	switch f.size() {
+	case 1:
+		if f.unsigned {
+			return env.Int(int64(b[0]))
+		}
+		return env.Int(int64(int8(b[0])))
+	case 2:
+		x := order.Uint16(b)
+		if f.unsigned {
+			return env.Int(int64(x))
+		}
+		return env.Int(int64(int16(x)))
+	case 4:
+		x := order.Uint32(b)
+		if f.unsigned {
+			return env.Int(int64(x))
+		}
+		return env.Int(int64(int32(x)))
+	default:
+		x := order.Uint64(b)
+		if f.unsigned && x > math.MaxInt64 {
+			return env.Str(strconv.FormatUint(x, 10))
+		}
+		return env.Int(int64(x))
+	}
+}
+
+func binaryEncodeBase64(i Interpreter, args []env.Value) (env.Value, error) {
+	str := base64.StdEncoding.EncodeToString(toBytes(slices.Fst(args).String()))
+	return wrapEncoded(i, []string{str})
+}
+
+func binaryEncodeHex(i Interpreter, args []env.Value) (env.Value, error) {
+	str := hex.EncodeToString(toBytes(slices.Fst(args).String()))
+	return env.Str(str), nil
+}
+
+func binaryEncodeUu(i Interpreter, args []env.Value) (env.Value, error) {
+	maxlen, err := resolveMaxlen(i)
+	if err != nil {
+		return nil, err
+	}
+	if maxlen == 0 {
+		maxlen = 61
+	}
+	if maxlen < 5 || maxlen > 85 {
+		return nil, fmt.Errorf("line length out of range")
+	}
+	var (
+		data  = toBytes(slices.Fst(args).String())
+		size  = (maxlen - 1) / 4 * 3
+		lines []string
+	)
+	for len(data) > 0 {
+		n := size
+		if n > len(data) {
+			n = len(data)
+		}
+		lines = append(lines, uuencodeLine(data[:n]))
+		data = data[n:]
+	}
+	wrap, _ := i.Resolve("wrapchar")
+	if len(lines) == 0 {
+		return env.EmptyStr(), nil
+	}
+	return env.Str(strings.Join(lines, wrap.String()) + wrap.String()), nil
+}
+
+func wrapEncoded(i Interpreter, list []string) (env.Value, error) {
+	maxlen, err := resolveMaxlen(i)
+	if err != nil {
+		return nil, err
+	}
+	str := strings.Join(list, "")
+	if maxlen <= 0 {
+		return env.Str(str), nil
+	}
+	var (
+		wrap, _ = i.Resolve("wrapchar")
+		lines   []string
+	)
+	for len(str) > maxlen {
+		lines = append(lines, str[:maxlen])
+		str = str[maxlen:]
+	}
+	lines = append(lines, str)
+	return env.Str(strings.Join(lines, wrap.String())), nil
+}
+
+func resolveMaxlen(i Interpreter) (int, error) {
+	v, err := i.Resolve("maxlen")
+	if err != nil {
+		return 0, err
+	}
+	n, err := env.ToInt(v)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("line length out of range")
+	}
+	return n, nil
+}
+
+func uuencodeLine(data []byte) string {
+	char := func(c byte) byte {
+		if c == 0 {
+			return '`'
+		}
+		return c + ' '
+	}
+	var buf strings.Builder
+	buf.WriteByte(char(byte(len(data))))
+	for j := 0; j < len(data); j += 3 {
+		var group [3]byte
+		copy(group[:], data[j:])
+		buf.WriteByte(char(group[0] >> 2))
+		buf.WriteByte(char((group[0]<<4 | group[1]>>4) & 0x3F))
+		buf.WriteByte(char((group[1]<<2 | group[2]>>6) & 0x3F))
+		buf.WriteByte(char(group[2] & 0x3F))
+	}
+	return buf.String()
+}
+
+func binaryDecodeBase64(i Interpreter, args []env.Value) (env.Value, error) {
+	str, err := decodeInput(i, slices.Fst(args).String())
+	if err != nil {
+		return nil, err
+	}
+	codec := base64.StdEncoding
+	if len(str)%4 != 0 && !isStrict(i) {
+		codec = base64.RawStdEncoding
+		str = strings.TrimRight(str, "=")
+	}
+	b, err := codec.DecodeString(str)
+	if err != nil {
+		return nil, fmt.Errorf("invalid base64 data: %w", err)
+	}
+	return env.Str(fromBytes(b)), nil
+}
+
+func binaryDecodeHex(i Interpreter, args []env.Value) (env.Value, error) {
+	str, err := decodeInput(i, slices.Fst(args).String())
+	if err != nil {
+		return nil, err
+	}
+	b, err := hex.DecodeString(str)
+	if err != nil {
+		return nil, fmt.Errorf("invalid hexadecimal data: %w", err)
+	}
+	return env.Str(fromBytes(b)), nil
+}
+
+func binaryDecodeUu(i Interpreter, args []env.Value) (env.Value, error) {
+	var (
+		strict = isStrict(i)
+		out    []byte
+	)
+	value := func(c byte) (byte, error) {
+		if c < ' ' || c > '`' {
+			return 0, fmt.Errorf("invalid uuencode character %q", c)
+		}
+		return (c - ' ') & 0x3F, nil
+	}
+	for _, line := range strings.Split(slices.Fst(args).String(), "\n") {
+		line = strings.TrimRight(line, "\r")
+		if !strict {
+			line = strings.TrimRightFunc(line, unicode.IsSpace)
+		}
+		if line == "" {
+			continue
+		}
+		size, err := value(line[0])
+		if err != nil {
+			return nil, err
+		}
+		var chunk []byte
+		for j := 1; j < len(line); j += 4 {
+			var group [4]byte
+			for k := 0; k < 4; k++ {
+				c := byte('`')
+				if j+k < len(line) {
+					c = line[j+k]
+				}
+				if group[k], err = value(c); err != nil {
+					return nil, err
+				}
+			}
+			chunk = append(chunk, group[0]<<2|group[1]>>4, group[1]<<4|group[2]>>2, group[2]<<6|group[3])
+		}
+		if int(size) > len(chunk) {
+			if strict {
+				return nil, fmt.Errorf("uuencode line too short")
+			}
+			size = byte(len(chunk))
+		}
+		out = append(out, chunk[:size]...)
+	}
+	return env.Str(fromBytes(out)), nil
+}
+
+func decodeInput(i Interpreter, str string) (string, error) {
+	if !isStrict(i) {
+		return strings.Join(strings.Fields(str), ""), nil
+	}
+	if strings.IndexFunc(str, unicode.IsSpace) >= 0 {
+		return "", fmt.Errorf("invalid whitespace in encoded data")
+	}
+	return str, nil
+}
+
+func isStrict(i Interpreter) bool {
+	v, _ := i.Resolve("strict")
+	return env.ToBool(v)
+}
+
+func toBytes(str string) []byte {
+	codec, _ := encoding.Lookup("binary")
+	return codec.Encode(str)
+}
+
+func fromBytes(b []byte) string {
+	codec, _ := encoding.Lookup("binary")
+	str, _ := codec.Decode(b, true)
+	return str
+}
@@ -0,0 +1,84 @@
+package stdlib
+
+import (
+	"fmt"
+
+	"github.com/midbel/gotcl/env"
+	"github.com/midbel/slices"
+)
+
+type CoroutineHandler interface {
+	Interpreter
+
+	Coroutine(string, []env.Value) (env.Value, error)
+	Yield(env.Value) (env.Value, error)
+	YieldTo([]env.Value) (env.Value, error)
+	CurrentCoroutine() string
+}
+
+type coroutineFunc func(CoroutineHandler, []env.Value) (env.Value, error)
+
+func wrapCoroutineFunc(do coroutineFunc) CommandFunc {
+	return func(i Interpreter, args []env.Value) (env.Value, error) {
+		ch, ok := i.(CoroutineHandler)
+		if !ok {
+			return nil, fmt.Errorf("interpreter can not handle coroutines")
+		}
+		return do(ch, args)
+	}
+}
+
+func RunCoroutine() Executer {
+	return Builtin{
+		Name:     "coroutine",
+		Help:     "create and run a coroutine",
+		Arity:    2,
+		Variadic: true,
+		Safe:     true,
+		Run:      wrapCoroutineFunc(runCoroutine),
+	}
+}
+
+func RunYield() Executer {
+	return Builtin{
+		Name:     "yield",
+		Help:     "suspend the current coroutine",
+		Variadic: true,
+		Safe:     true,
+		Run:      wrapCoroutineFunc(runYield),
+	}
+}
+
+func RunYieldTo() Executer {
+	return Builtin{
+		Name:     "yieldto",
+		Help:     "suspend the current coroutine and execute a command in its caller",
+		Arity:    1,
+		Variadic: true,
+		Safe:     true,
+		Run:      wrapCoroutineFunc(runYieldTo),
+	}
+}
+
+func runCoroutine(ch CoroutineHandler, args []env.Value) (env.Value, error) {
+	return ch.Coroutine(slices.Fst(args).String(), slices.Rest(args))
+}
+
+func runYield(ch CoroutineHandler, args []env.Value) (env.Value, error) {
+	if len(args) > 1 {
+		return nil, fmt.Errorf("yield: %w", ErrArgument)
+	}
+	value := slices.Fst(args)
+	if value == nil {
+		value = env.EmptyStr()
+	}
+	return ch.Yield(value)
+}
+
+func runYieldTo(ch CoroutineHandler, args []env.Value) (env.Value, error) {
+	return ch.YieldTo(args)
+}
+
+func infoCoroutine(ch CoroutineHandler, args []env.Value) (env.Value, error) {
+	return env.Str(ch.CurrentCoroutine()), nil
+}
@@ -0,0 +1,586 @@
+package stdlib
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/midbel/gotcl/env"
+	"github.com/midbel/gotcl/glob"
+	"github.com/midbel/slices"
+)
+
+func MakeDict() Executer {
+	e := Ensemble{
+		Name: "dict",
+		Safe: true,
+		List: []Executer{
+			Builtin{
+				Name:     "create",
+				Variadic: true,
+				Safe:     true,
+				Run:      dictCreate,
+			},
+			Builtin{
+				Name:     "get",
+				Arity:    1,
+				Variadic: true,
+				Safe:     true,
+				Run:      dictGet,
+			},
+			Builtin{
+				Name:     "set",
+				Arity:    3,
+				Variadic: true,
+				Safe:     true,
+				Run:      dictSet,
+			},
+			Builtin{
+				Name:     "unset",
+				Arity:    2,
+				Variadic: true,
+				Safe:     true,
+				Run:      dictUnset,
+			},
+			Builtin{
+				Name:     "exists",
+				Arity:    2,
+				Variadic: true,
+				Safe:     true,
+				Run:      dictExists,
+			},
+			Builtin{
+				Name:     "keys",
+				Arity:    1,
+				Variadic: true,
+				Safe:     true,
+				Run:      dictKeys,
+			},
+			Builtin{
+				Name:     "values",
+				Arity:    1,
+				Variadic: true,
+				Safe:     true,
+				Run:      dictValues,
+			},
+			Builtin{
+				Name:  "size",
+				Arity: 1,
+				Safe:  true,
+				Run:   dictSize,
+			},
+			Builtin{
+				Name:  "for",
+				Arity: 3,
+				Safe:  true,
+				Run:   dictFor,
+			},
+			Builtin{
+				Name:  "map",
+				Arity: 3,
+				Safe:  true,
+				Run:   dictMap,
+			},
+			Builtin{
+				Name:     "filter",
+				Arity:    2,
+				Variadic: true,
+				Safe:     true,
+				Run:      dictFilter,
+			},
+			Builtin{
+				Name:     "merge",
+				Variadic: true,
+				Safe:     true,
+				Run:      dictMerge,
+			},
+			Builtin{
+				Name:     "replace",
+				Arity:    1,
+				Variadic: true,
+				Safe:     true,
+				Run:      dictReplace,
+			},
+			Builtin{
+				Name:     "remove",
+				Arity:    1,
+				Variadic: true,
+				Safe:     true,
+				Run:      dictRemove,
+			},
+			Builtin{
+				Name:     "update",
+				Arity:    4,
+				Variadic: true,
+				Safe:     true,
+				Run:      dictUpdate,
+			},
+			Builtin{
+				Name:     "with",
+				Arity:    2,
+				Variadic: true,
+				Safe:     true,
+				Run:      dictWith,
+			},
+			Builtin{
+				Name:     "append",
+				Arity:    2,
+				Variadic: true,
+				Safe:     true,
+				Run:      dictAppend,
+			},
+			Builtin{
+				Name:     "lappend",
+				Arity:    2,
+				Variadic: true,
+				Safe:     true,
+				Run:      dictLappend,
+			},
+			Builtin{
+				Name:     "incr",
+				Arity:    2,
+				Variadic: true,
+				Safe:     true,
+				Run:      dictIncr,
+			},
+		},
+	}
+	return sortEnsembleCommands(e)
+}
+
+func dictCreate(i Interpreter, args []env.Value) (env.Value, error) {
+	if len(args)%2 != 0 {
+		return nil, fmt.Errorf("dict create: %w: missing value to go with key", ErrArgument)
+	}
+	dict := env.EmptyDict()
+	for j := 0; j < len(args); j += 2 {
+		dict = dict.(env.Dict).Set(args[j].String(), args[j+1])
+	}
+	return dict, nil
+}
+
+func dictGet(i Interpreter, args []env.Value) (env.Value, error) {
+	dict, err := slices.Fst(args).ToDict()
+	if err != nil {
+		return nil, err
+	}
+	return dict.(env.Dict).GetPath(toKeys(slices.Rest(args)))
+}
+
+func dictSet(i Interpreter, args []env.Value) (env.Value, error) {
+	var (
+		name = slices.Fst(args).String()
+		keys = toKeys(slices.Slice(slices.Rest(args)))
+	)
+	dict, err := resolveDict(i, name)
+	if err != nil {
+		return nil, err
+	}
+	res, err := dict.SetPath(keys, slices.Lst(args))
+	if err != nil {
+		return nil, err
+	}
+	i.Define(name, res)
+	return res, nil
+}
+
+func dictUnset(i Interpreter, args []env.Value) (env.Value, error) {
+	name := slices.Fst(args).String()
+	dict, err := resolveDict(i, name)
+	if err != nil {
+		return nil, err
+	}
+	res, err := dict.UnsetPath(toKeys(slices.Rest(args)))
+	if err != nil {
+		return nil, err
+	}
+	i.Define(name, res)
+	return res, nil
+}
+
+func dictExists(i Interpreter, args []env.Value) (env.Value, error) {
+	dict, err := slices.Fst(args).ToDict()
+	if err != nil {
+		return env.False(), nil
+	}
+	_, err = dict.(env.Dict).GetPath(toKeys(slices.Rest(args)))
+	return env.Bool(err == nil), nil
+}
+
+func dictKeys(i Interpreter, args []env.Value) (env.Value, error) {
+	dict, err := slices.Fst(args).ToDict()
+	if err != nil {
+		return nil, err
+	}
+	keys := dict.(env.Dict).Keys()
+	if pat := slices.Snd(args); pat != nil {
+		keys = glob.Filter(keys, pat.String())
+	}
+	return env.ListFromStrings(keys), nil
+}
+
+func dictValues(i Interpreter, args []env.Value) (env.Value, error) {
+	dict, err := slices.Fst(args).ToDict()
+	if err != nil {
+		return nil, err
+	}
+	values := dict.(env.Dict).Values()
+	if pat := slices.Snd(args); pat != nil {
+		values = slices.Filter(values, func(v env.Value) bool {
+			return glob.Match(v.String(), pat.String())
+		})
+	}
+	return env.ListFrom(values...), nil
+}
+
+func dictSize(i Interpreter, args []env.Value) (env.Value, error) {
+	dict, err := slices.Fst(args).ToDict()
+	if err != nil {
+		return nil, err
+	}
+	return env.Int(int64(dict.(env.Dict).Len())), nil
+}
+
+func dictFor(i Interpreter, args []env.Value) (env.Value, error) {
+	var res env.Value
+	err := iterDict(i, args, func(_ string, v env.Value) error {
+		res = v
+		return nil
+	})
+	if res == nil {
+		res = env.EmptyStr()
+	}
+	return res, err
+}
+
+func dictMap(i Interpreter, args []env.Value) (env.Value, error) {
+	res := env.EmptyDict()
+	err := iterDict(i, args, func(k string, v env.Value) error {
+		res = res.(env.Dict).Set(k, v)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+func dictFilter(i Interpreter, args []env.Value) (env.Value, error) {
+	dict, err := slices.Fst(args).ToDict()
+	if err != nil {
+		return nil, err
+	}
+	var (
+		src  = dict.(env.Dict)
+		res  = env.EmptyDict()
+		rest = slices.Take(args, 2)
+	)
+	switch kind := slices.Snd(args).String(); kind {
+	case "key", "value":
+		for _, k := range src.Keys() {
+			str := k
+			if kind == "value" {
+				str = src.Get(k).String()
+			}
+			ok := slices.Some(rest, func(pat env.Value) bool {
+				return glob.Match(str, pat.String())
+			})
+			if ok {
+				res = res.(env.Dict).Set(k, src.Get(k))
+			}
+		}
+	case "script":
+		if len(rest) != 2 {
+			return nil, fmt.Errorf("dict filter: %w", ErrArgument)
+		}
+		kv, err := dictVars(slices.Fst(rest))
+		if err != nil {
+			return nil, err
+		}
+		for _, k := range src.Keys() {
+			i.Define(kv[0], env.Str(k))
+			i.Define(kv[1], src.Get(k))
+			ok, err := testScript(i, slices.Snd(rest))
+			if err != nil && !errors.Is(err, ErrContinue) {
+				if errors.Is(err, ErrBreak) {
+					break
+				}
+				return nil, err
+			}
+			if ok && err == nil {
+				res = res.(env.Dict).Set(k, src.Get(k))
+			}
+		}
+	default:
+		return nil, fmt.Errorf("%s: unknown filter type", kind)
+	}
+	return res, nil
+}
+
+func dictMerge(i Interpreter, args []env.Value) (env.Value, error) {
+	res := env.EmptyDict()
+	for _, a := range args {
+		dict, err := a.ToDict()
+		if err != nil {
+			return nil, err
+		}
+		res = res.(env.Dict).Merge(dict.(env.Dict))
+	}
+	return res, nil
+}
+
+func dictReplace(i Interpreter, args []env.Value) (env.Value, error) {
+	dict, err := slices.Fst(args).ToDict()
+	if err != nil {
+		return nil, err
+	}
+	rest := slices.Rest(args)
+	if len(rest)%2 != 0 {
+		return nil, fmt.Errorf("dict replace: %w: missing value to go with key", ErrArgument)
+	}
+	for j := 0; j < len(rest); j += 2 {
+		dict = dict.(env.Dict).Set(rest[j].String(), rest[j+1])
+	}
+	return dict, nil
+}
+
+func dictRemove(i Interpreter, args []env.Value) (env.Value, error) {
+	dict, err := slices.Fst(args).ToDict()
+	if err != nil {
+		return nil, err
+	}
+	for _, k := range slices.Rest(args) {
+		dict = dict.(env.Dict).Unset(k.String())
+	}
+	return dict, nil
+}
+
+func dictUpdate(i Interpreter, args []env.Value) (env.Value, error) {
+	var (
+		name  = slices.Fst(args).String()
+		pairs = slices.Slice(slices.Rest(args))
+		body  = slices.Lst(args)
+	)
+	if len(pairs)%2 != 0 {
+		return nil, fmt.Errorf("dict update: %w", ErrArgument)
+	}
+	dict, err := resolveDict(i, name)
+	if err != nil {
+		return nil, err
+	}
+	for j := 0; j < len(pairs); j += 2 {
+		var (
+			key = pairs[j].String()
+			str = pairs[j+1].String()
+		)
+		if dict.Has(key) {
+			i.Define(str, dict.Get(key))
+		} else {
+			i.Delete(str)
+		}
+	}
+	res, err := executeValue(i, body)
+	if err != nil {
+		return nil, err
+	}
+	dict, err = resolveDict(i, name)
+	if err != nil {
+		return nil, err
+	}
+	var upd env.Value = dict
+	for j := 0; j < len(pairs); j += 2 {
+		key := pairs[j].String()
+		if v, err := i.Resolve(pairs[j+1].String()); err == nil {
+			upd = upd.(env.Dict).Set(key, v)
+		} else {
+			upd = upd.(env.Dict).Unset(key)
+		}
+	}
+	i.Define(name, upd)
+	return res, nil
+}
+
+func dictWith(i Interpreter, args []env.Value) (env.Value, error) {
+	var (
+		name = slices.Fst(args).String()
+		keys = toKeys(slices.Slice(slices.Rest(args)))
+		body = slices.Lst(args)
+	)
+	dict, err := resolveDict(i, name)
+	if err != nil {
+		return nil, err
+	}
+	inner, err := dict.GetPath(keys)
+	if err != nil {
+		return nil, err
+	}
+	if inner, err = inner.ToDict(); err != nil {
+		return nil, err
+	}
+	names := inner.(env.Dict).Keys()
+	for _, k := range names {
+		i.Define(k, inner.(env.Dict).Get(k))
+	}
+	res, err := executeValue(i, body)
+	if err != nil {
+		return nil, err
+	}
+	for _, k := range names {
+		if v, err := i.Resolve(k); err == nil {
+			inner = inner.(env.Dict).Set(k, v)
+		} else {
+			inner = inner.(env.Dict).Unset(k)
+		}
+	}
+	if dict, err = resolveDict(i, name); err != nil {
+		return nil, err
+	}
+	upd, err := dict.SetPath(keys, inner)
+	if err != nil {
+		return nil, err
+	}
+	i.Define(name, upd)
+	return res, nil
+}
+
+func dictAppend(i Interpreter, args []env.Value) (env.Value, error) {
+	return updateDict(i, args, func(v env.Value, rest []env.Value) (env.Value, error) {
+		var str strings.Builder
+		if v != nil {
+			str.WriteString(v.String())
+		}
+		for _, r := range rest {
+			str.WriteString(r.String())
+		}
+		return env.Str(str.String()), nil
+	})
+}
+
+func dictLappend(i Interpreter, args []env.Value) (env.Value, error) {
+	return updateDict(i, args, func(v env.Value, rest []env.Value) (env.Value, error) {
+		var list []env.Value
+		if v != nil {
+			vs, err := listValues(v)
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, vs...)
+		}
+		list = append(list, rest...)
+		return env.ListFrom(list...), nil
+	})
+}
+
+func dictIncr(i Interpreter, args []env.Value) (env.Value, error) {
+	return updateDict(i, args, func(v env.Value, rest []env.Value) (env.Value, error) {
+		var (
+			step = 1
+			curr int
+			err  error
+		)
+		if len(rest) > 1 {
+			return nil, fmt.Errorf("dict incr: %w", ErrArgument)
+		}
+		if len(rest) == 1 {
+			if step, err = env.ToInt(slices.Fst(rest)); err != nil {
+				return nil, err
+			}
+		}
+		if v != nil {
+			if curr, err = env.ToInt(v); err != nil {
+				return nil, err
+			}
+		}
+		return env.Int(int64(curr + step)), nil
+	})
+}
+
+func updateDict(i Interpreter, args []env.Value, do func(env.Value, []env.Value) (env.Value, error)) (env.Value, error) {
+	var (
+		name = slices.Fst(args).String()
+		key  = slices.Snd(args).String()
+	)
+	dict, err := resolveDict(i, name)
+	if err != nil {
+		return nil, err
+	}
+	val, err := do(dict.Get(key), slices.Take(args, 2))
+	if err != nil {
+		return nil, err
+	}
+	res := dict.Set(key, val)
+	i.Define(name, res)
+	return res, nil
+}
+
+func iterDict(i Interpreter, args []env.Value, do func(string, env.Value) error) error {
+	kv, err := dictVars(slices.Fst(args))
+	if err != nil {
+		return err
+	}
+	dict, err := slices.Snd(args).ToDict()
+	if err != nil {
+		return err
+	}
+	src := dict.(env.Dict)
+	for _, k := range src.Keys() {
+		i.Define(kv[0], env.Str(k))
+		i.Define(kv[1], src.Get(k))
+		res, err := executeValue(i, slices.Lst(args))
+		if err != nil && !errors.Is(err, ErrContinue) {
+			if errors.Is(err, ErrBreak) {
+				break
+			}
+			return err
+		}
+		if err != nil {
+			continue
+		}
+		if err := do(k, res); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func resolveDict(i Interpreter, name string) (env.Dict, error) {
+	v, err := i.Resolve(name)
+	if err != nil {
+		return env.EmptyDict().(env.Dict), nil
+	}
+	v, err = v.ToDict()
+	if err != nil {
+		return env.Dict{}, err
+	}
+	return v.(env.Dict), nil
+}
+
+func dictVars(v env.Value) ([]string, error) {
+	vs, err := listValues(v)
+	if err != nil {
+		return nil, err
+	}
+	if len(vs) != 2 {
+		return nil, fmt.Errorf("must have exactly two variable names")
+	}
+	return []string{vs[0].String(), vs[1].String()}, nil
+}
+
+func listValues(v env.Value) ([]env.Value, error) {
+	list, err := v.ToList()
+	if err != nil {
+		return nil, err
+	}
+	if x, ok := list.(env.List); ok {
+		return x.Values(), nil
+	}
+	return []env.Value{list}, nil
+}
+
+func toKeys(args []env.Value) []string {
+	var keys []string
+	for _, a := range args {
+		keys = append(keys, a.String())
+	}
+	return keys
+}
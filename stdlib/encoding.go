@@ -0,0 +1,92 @@
+package stdlib
+
+import (
+	"fmt"
+
+	"github.com/midbel/gotcl/env"
+	"github.com/midbel/gotcl/stdlib/encoding"
+	"github.com/midbel/slices"
+)
+
+func MakeEncoding() Executer {
+	e := Ensemble{
+		Name: "encoding",
+		Help: "convert strings from and to various character encodings",
+		Safe: true,
+		List: []Executer{
+			Builtin{
+				Name:     "convertto",
+				Arity:    1,
+				Variadic: true,
+				Run:      encodingConvertTo,
+			},
+			Builtin{
+				Name:     "convertfrom",
+				Arity:    1,
+				Variadic: true,
+				Run:      encodingConvertFrom,
+			},
+			Builtin{
+				Name: "names",
+				Run:  encodingNames,
+			},
+			Builtin{
+				Name:     "system",
+				Variadic: true,
+				Run:      encodingSystem,
+			},
+		},
+	}
+	return sortEnsembleCommands(e)
+}
+
+func encodingConvertTo(i Interpreter, args []env.Value) (env.Value, error) {
+	codec, data, err := encodingArgs(args)
+	if err != nil {
+		return nil, err
+	}
+	return env.Str(fromBytes(codec.Encode(data))), nil
+}
+
+func encodingConvertFrom(i Interpreter, args []env.Value) (env.Value, error) {
+	codec, data, err := encodingArgs(args)
+	if err != nil {
+		return nil, err
+	}
+	str, _ := codec.Decode(toBytes(data), true)
+	return env.Str(str), nil
+}
+
+func encodingNames(i Interpreter, args []env.Value) (env.Value, error) {
+	return env.ListFromStrings(encoding.Names()), nil
+}
+
+func encodingSystem(i Interpreter, args []env.Value) (env.Value, error) {
+	if len(args) > 1 {
+		return nil, fmt.Errorf("encoding system: %w", ErrArgument)
+	}
+	if len(args) == 1 {
+		name := slices.Fst(args).String()
+		if err := encoding.SetSystem(name); err != nil {
+			return nil, fmt.Errorf("unknown encoding %q", name)
+		}
+		return env.EmptyStr(), nil
+	}
+	return env.Str(encoding.System()), nil
+}
+
+func encodingArgs(args []env.Value) (encoding.Encoding, string, error) {
+	name := encoding.System()
+	switch len(args) {
+	case 1:
+	case 2:
+		name = slices.Fst(args).String()
+	default:
+		return nil, "", ErrArgument
+	}
+	codec, err := encoding.Lookup(name)
+	if err != nil {
+		return nil, "", fmt.Errorf("unknown encoding %q", name)
+	}
+	return codec, slices.Lst(args).String(), nil
+}
@@ -0,0 +1,209 @@
+package encoding
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+var ErrEncoding = errors.New("unknown encoding")
+
+var system = "utf-8"
+
+type Encoding interface {
+	Decode([]byte, bool) (string, int)
+	Encode(string) []byte
+}
+
+var encodings = map[string]Encoding{
+	"utf-8":      utf8Encoding{},
+	"binary":     byteEncoding{max: 0xFF, wrap: true},
+	"iso8859-1":  byteEncoding{max: 0xFF},
+	"ascii":      byteEncoding{max: 0x7F},
+	"utf-16le":   utf16Encoding{order: binary.LittleEndian},
+	"utf-16be":   utf16Encoding{order: binary.BigEndian},
+	"iso8859-2":  makeTableEncoding(&iso8859_2),
+	"iso8859-3":  makeTableEncoding(&iso8859_3),
+	"iso8859-4":  makeTableEncoding(&iso8859_4),
+	"iso8859-5":  makeTableEncoding(&iso8859_5),
+	"iso8859-6":  makeTableEncoding(&iso8859_6),
+	"iso8859-7":  makeTableEncoding(&iso8859_7),
+	"iso8859-8":  makeTableEncoding(&iso8859_8),
+	"iso8859-9":  makeTableEncoding(&iso8859_9),
+	"iso8859-10": makeTableEncoding(&iso8859_10),
+	"iso8859-11": makeTableEncoding(&iso8859_11),
+	"iso8859-13": makeTableEncoding(&iso8859_13),
+	"iso8859-14": makeTableEncoding(&iso8859_14),
+	"iso8859-15": makeTableEncoding(&iso8859_15),
+	"iso8859-16": makeTableEncoding(&iso8859_16),
+	"cp1252":     makeTableEncoding(&cp1252),
+}
+
+func System() string {
+	return system
+}
+
+func SetSystem(name string) error {
+	if _, err := Lookup(name); err != nil {
+		return err
+	}
+	system = strings.ToLower(name)
+	return nil
+}
+
+func Lookup(name string) (Encoding, error) {
+	e, ok := encodings[strings.ToLower(name)]
+	if !ok {
+		return nil, fmt.Errorf("%s: %w", name, ErrEncoding)
+	}
+	return e, nil
+}
+
+func Names() []string {
+	var list []string
+	for n := range encodings {
+		list = append(list, n)
+	}
+	sort.Strings(list)
+	return list
+}
+
+type utf8Encoding struct{}
+
+func (_ utf8Encoding) Decode(b []byte, final bool) (string, int) {
+	n := len(b)
+	if !final {
+		n = completeRunes(b)
+	}
+	return string(b[:n]), n
+}
+
+func (_ utf8Encoding) Encode(str string) []byte {
+	return []byte(str)
+}
+
+func completeRunes(b []byte) int {
+	for i := len(b) - 1; i >= 0 && i >= len(b)-utf8.UTFMax; i-- {
+		if !utf8.RuneStart(b[i]) {
+			continue
+		}
+		if !utf8.FullRune(b[i:]) {
+			return i
+		}
+		break
+	}
+	return len(b)
+}
+
+type byteEncoding struct {
+	max  rune
+	wrap bool
+}
+
+func (e byteEncoding) Decode(b []byte, _ bool) (string, int) {
+	var buf strings.Builder
+	for _, c := range b {
+		buf.WriteRune(rune(c))
+	}
+	return buf.String(), len(b)
+}
+
+func (e byteEncoding) Encode(str string) []byte {
+	b := make([]byte, 0, len(str))
+	for _, r := range str {
+		switch {
+		case r <= e.max:
+			b = append(b, byte(r))
+		case e.wrap:
+			b = append(b, byte(r&0xFF))
+		default:
+			b = append(b, '?')
+		}
+	}
+	return b
+}
+
+type tableEncoding struct {
+	table   *[128]rune
+	reverse map[rune]byte
+}
+
+func makeTableEncoding(table *[128]rune) tableEncoding {
+	e := tableEncoding{
+		table:   table,
+		reverse: make(map[rune]byte),
+	}
+	for i, r := range table {
+		if r == utf8.RuneError {
+			continue
+		}
+		e.reverse[r] = byte(i + 0x80)
+	}
+	return e
+}
+
+func (e tableEncoding) Decode(b []byte, _ bool) (string, int) {
+	var buf strings.Builder
+	for _, c := range b {
+		if c < 0x80 {
+			buf.WriteByte(c)
+			continue
+		}
+		buf.WriteRune(e.table[c-0x80])
+	}
+	return buf.String(), len(b)
+}
+
+func (e tableEncoding) Encode(str string) []byte {
+	b := make([]byte, 0, len(str))
+	for _, r := range str {
+		if r < 0x80 {
+			b = append(b, byte(r))
+			continue
+		}
+		c, ok := e.reverse[r]
+		if !ok {
+			c = '?'
+		}
+		b = append(b, c)
+	}
+	return b
+}
+
+type utf16Encoding struct {
+	order binary.ByteOrder
+}
+
+func (e utf16Encoding) Decode(b []byte, final bool) (string, int) {
+	var (
+		list []uint16
+		n    int
+	)
+	for ; n+1 < len(b); n += 2 {
+		list = append(list, e.order.Uint16(b[n:]))
+	}
+	if z := len(list); !final && z > 0 && utf16.IsSurrogate(rune(list[z-1])) && list[z-1] < 0xDC00 {
+		list = list[:z-1]
+		n -= 2
+	}
+	if final && n < len(b) {
+		list = append(list, utf8.RuneError)
+		n = len(b)
+	}
+	return string(utf16.Decode(list)), n
+}
+
+func (e utf16Encoding) Encode(str string) []byte {
+	var (
+		list = utf16.Encode([]rune(str))
+		b    = make([]byte, len(list)*2)
+	)
+	for i, c := range list {
+		e.order.PutUint16(b[i*2:], c)
+	}
+	return b
+}
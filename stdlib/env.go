@@ -10,10 +10,11 @@ import (
 
 func RunSet() Executer {
 	return Builtin{
-		Name:  "set",
-		Arity: 2,
-		Safe:  true,
-		Run:   runSet,
+		Name:     "set",
+		Arity:    1,
+		Variadic: true,
+		Safe:     true,
+		Run:      runSet,
 	}
 }
 
@@ -52,8 +53,25 @@ func RunAppend() Executer {
 }
 
 func runSet(i Interpreter, args []env.Value) (env.Value, error) {
-	i.Define(slices.Fst(args).String(), slices.Snd(args))
-	return slices.Snd(args), nil
+	switch len(args) {
+	case 1:
+		return i.Resolve(slices.Fst(args).String())
+	case 2:
+	default:
+		return nil, fmt.Errorf("wrong # args: should be \"set varName ?newValue?\"")
+	}
+	return setVar(i, slices.Fst(args).String(), slices.Snd(args))
+}
+
+func setVar(i Interpreter, name string, v env.Value) (env.Value, error) {
+	s, ok := i.(interface {
+		SetVar(string, env.Value) (env.Value, error)
+	})
+	if !ok {
+		i.Define(name, v)
+		return v, nil
+	}
+	return s.SetVar(name, v)
 }
 
 func runUnset(i Interpreter, args []env.Value) (env.Value, error) {
@@ -72,13 +90,12 @@ func runRename(i Interpreter, args []env.Value) (env.Value, error) {
 func runAppend(i Interpreter, args []env.Value) (env.Value, error) {
 	val, err := i.Resolve(slices.Fst(args).String())
 	if err != nil {
-		return nil, err
+		val = env.EmptyStr()
 	}
 	list := []string{val.String()}
 	for _, a := range slices.Rest(args) {
 		list = append(list, a.String())
 	}
 	val = env.Str(strings.Join(list, ""))
-	i.Define(slices.Fst(args).String(), val)
-	return val, nil
+	return setVar(i, slices.Fst(args).String(), val)
 }
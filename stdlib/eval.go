@@ -12,6 +12,7 @@ import (
 	"github.com/midbel/gotcl/expr"
 	"github.com/midbel/gotcl/expr/types"
 	"github.com/midbel/gotcl/glob"
+	"github.com/midbel/gotcl/word"
 	"github.com/midbel/slices"
 )
 
@@ -21,6 +22,7 @@ type DeferHandler interface {
 }
 
 type LinkHandler interface {
+	Depth() int
 	LinkVar(string, string, int) error
 }
 
@@ -65,7 +67,7 @@ func RunUpvar() Executer {
 func RunGlobal() Executer {
 	return Builtin{
 		Name:     "global",
-		Arity:    2,
+		Arity:    1,
 		Variadic: true,
 		Safe:     false,
 		Run:      runGlobal,
@@ -82,6 +84,47 @@ func RunEval() Executer {
 	}
 }
 
+func RunSubst() Executer {
+	return Builtin{
+		Name:  "subst",
+		Help:  "perform backslash, command and variable substitutions",
+		Arity: 1,
+		Safe:  true,
+		Options: []Option{
+			{
+				Name:  "nobackslashes",
+				Flag:  true,
+				Value: env.False(),
+				Check: CheckBool,
+			},
+			{
+				Name:  "nocommands",
+				Flag:  true,
+				Value: env.False(),
+				Check: CheckBool,
+			},
+			{
+				Name:  "novariables",
+				Flag:  true,
+				Value: env.False(),
+				Check: CheckBool,
+			},
+		},
+		Run: runSubst,
+	}
+}
+
+func RunApply() Executer {
+	return Builtin{
+		Name:     "apply",
+		Help:     "apply an anonymous function",
+		Arity:    1,
+		Variadic: true,
+		Safe:     true,
+		Run:      runApply,
+	}
+}
+
 func RunSource() Executer {
 	return Builtin{
 		Name:     "source",
@@ -188,7 +231,7 @@ func RunWhile() Executer {
 		Name:  "while",
 		Arity: 2,
 		Safe:  true,
-		Run:   runFor,
+		Run:   runWhile,
 	}
 }
 
@@ -210,6 +253,12 @@ func RunSwitch() Executer {
 				Value: env.False(),
 				Check: CheckBool,
 			},
+			{
+				Name:  "regexp",
+				Flag:  true,
+				Value: env.False(),
+				Check: CheckBool,
+			},
 			{
 				Name:  "nocase",
 				Flag:  true,
@@ -288,7 +337,7 @@ func runTry(i Interpreter, args []env.Value) (env.Value, error) {
 		args = slices.Take(args, len(args)-2)
 	}
 	var (
-		res, err = i.Execute(strings.NewReader(slices.Fst(args).String()))
+		res, err = executeValue(i, slices.Fst(args))
 		errtry   error
 		errfin   error
 	)
@@ -323,7 +372,7 @@ func runTry(i Interpreter, args []env.Value) (env.Value, error) {
 				default:
 				}
 				script := slices.At(args, j+3)
-				_, errtry = i.Execute(strings.NewReader(script.String()))
+				_, errtry = executeValue(i, script)
 				break
 			}
 		}
@@ -340,19 +389,32 @@ func runThrow(i Interpreter, args []env.Value) (env.Value, error) {
 
 func runCatch(i Interpreter, args []env.Value) (env.Value, error) {
 	var (
-		res, err = i.Execute(strings.NewReader(slices.Fst(args).String()))
-		name     = slices.Snd(args)
-		code     int64
+		res, err = executeValue(i, slices.Fst(args))
+		e        = Error{Code: ErrorOk}
 	)
 	if err != nil {
-		code = int64(ErrorErr)
-		if e, ok := err.(Error); ok {
-			code = int64(e.Code)
+		x, ok := err.(Error)
+		if !ok {
+			x = Error{
+				Err:  err,
+				Code: ErrorErr,
+			}
+		}
+		if x.ErrorCode != nil {
+			i.Define("errorCode", x.ErrorCode)
 		}
-		res = env.Str(err.Error())
+		if x.Code == ErrorErr {
+			i.Define("errorInfo", env.Str(x.Info()))
+		}
+		e, res = x, env.Str(err.Error())
+	}
+	if name := slices.Snd(args); name != nil {
+		i.Define(name.String(), res)
+	}
+	if name := slices.At(args, 2); name != nil {
+		i.Define(name.String(), e.Options())
 	}
-	i.Define(name.String(), res)
-	return env.Int(code), nil
+	return env.Int(int64(e.Code)), nil
 }
 
 func runError(i Interpreter, args []env.Value) (env.Value, error) {
@@ -383,11 +445,11 @@ func runIf(i Interpreter, args []env.Value) (env.Value, error) {
 	if v := slices.At(args, len(args)-2); v != nil {
 		if v.String() == "else" {
 			alt = slices.Lst(args).String()
-			args = slices.Take(args, len(args)-2)
+			args = args[:len(args)-2]
 		}
 	}
 	for len(args) > 0 {
-		b, err := testScript(i, slices.Fst(args))
+		b, err := testExpr(i, slices.Fst(args))
 		if err != nil {
 			return nil, ErrorFromError(err)
 		}
@@ -397,7 +459,7 @@ func runIf(i Interpreter, args []env.Value) (env.Value, error) {
 			args = slices.Take(args, 1)
 		}
 		if b {
-			return i.Execute(strings.NewReader(slices.Fst(args).String()))
+			return executeValue(i, slices.Fst(args))
 		}
 		args = slices.Rest(args)
 		if kw := slices.Fst(args); kw != nil && kw.String() == "elseif" {
@@ -422,7 +484,9 @@ func runSwitch(i Interpreter, args []env.Value) (env.Value, error) {
 		nocase, _ = i.Resolve("nocase")
 		exact, _  = i.Resolve("exact")
 		match, _  = i.Resolve("glob")
+		regex, _  = i.Resolve("regexp")
 		input     = slices.Fst(args).String()
+		orig      = input
 	)
 	if env.ToBool(nocase) {
 		input = strings.ToLower(input)
@@ -436,6 +500,16 @@ func runSwitch(i Interpreter, args []env.Value) (env.Value, error) {
 			alt = list[j+1]
 			break
 		}
+		if env.ToBool(regex) {
+			re, err := compileRegexp(list[j], env.ToBool(nocase), false)
+			if err != nil {
+				return nil, err
+			}
+			if re.MatchString(orig) {
+				return i.Execute(strings.NewReader(list[j+1]))
+			}
+			continue
+		}
 		pat := list[j]
 		if env.ToBool(nocase) {
 			pat = strings.ToLower(pat)
@@ -466,7 +540,7 @@ func runForeach(i Interpreter, args []env.Value) (env.Value, error) {
 		}
 		for _, a := range list.(env.List).Values() {
 			i.Define(slices.At(args, j).String(), a)
-			res, err = i.Execute(strings.NewReader(slices.At(args, j+2).String()))
+			res, err = executeValue(i, slices.At(args, j+2))
 			if err != nil && !errors.Is(err, ErrContinue) {
 				if errors.Is(err, ErrBreak) {
 					break
@@ -479,7 +553,7 @@ func runForeach(i Interpreter, args []env.Value) (env.Value, error) {
 }
 
 func runFor(i Interpreter, args []env.Value) (env.Value, error) {
-	_, err := i.Execute(strings.NewReader(slices.Fst(args).String()))
+	_, err := executeValue(i, slices.Fst(args))
 	if err != nil {
 		return nil, err
 	}
@@ -503,43 +577,48 @@ func runContinue(i Interpreter, args []env.Value) (env.Value, error) {
 }
 
 func runExpr(i Interpreter, args []env.Value) (env.Value, error) {
-	var str strings.Builder
-	for i := range args {
-		str.WriteString(args[i].String())
-	}
-	p, err := expr.Parse(str.String())
-	if err != nil {
-		return nil, err
+	src := slices.Fst(args)
+	if len(args) > 1 {
+		var str strings.Builder
+		for i := range args {
+			if i > 0 {
+				str.WriteString(" ")
+			}
+			str.WriteString(args[i].String())
+		}
+		src = env.Str(str.String())
 	}
-	expr, err := p.Parse()
+	res, err := evalExpr(i, src)
 	if err != nil {
 		return nil, err
 	}
-	res, err := expr.Eval(i)
+	return types.AsEnv(res), nil
+}
+
+func evalExpr(i Interpreter, src env.Value) (types.Value, error) {
+	x, err := env.Internal(src, "expr", func(str string) (any, error) {
+		return compileExpr(i, str)
+	})
 	if err != nil {
 		return nil, err
 	}
-	var val env.Value
-	switch res.(type) {
-	case types.Boolean:
-		b, _ := types.AsBool(res)
-		val = env.Bool(b)
-	case types.Integer:
-		i, _ := types.AsInt(res)
-		val = env.Int(i)
-	case types.Real:
-		f, _ := types.AsFloat(res)
-		val = env.Float(f)
-	default:
-		return env.Str(res.String()), nil
+	return x.(expr.Expression).Eval(i)
+}
+
+func compileExpr(i Interpreter, str string) (expr.Expression, error) {
+	c, ok := i.(interface {
+		CompileExpr(string) (expr.Expression, error)
+	})
+	if !ok {
+		return expr.Compile(str)
 	}
-	return val, nil
+	return c.CompileExpr(str)
 }
 
 func runTime(i Interpreter, args []env.Value) (env.Value, error) {
 	var (
 		now    = time.Now()
-		_, err = i.Execute(strings.NewReader(slices.Fst(args).String()))
+		_, err = executeValue(i, slices.Fst(args))
 	)
 	return env.Str(time.Since(now).String()), err
 }
@@ -560,7 +639,37 @@ func runExit(i Interpreter, args []env.Value) (env.Value, error) {
 
 func runEval(i Interpreter, args []env.Value) (env.Value, error) {
 	tmp := env.ListFrom(args...)
-	return i.Execute(strings.NewReader(tmp.String()))
+	return executeValue(i, tmp)
+}
+
+func runSubst(i Interpreter, args []env.Value) (env.Value, error) {
+	s, ok := i.(interface {
+		Subst(string, int) (env.Value, error)
+	})
+	if !ok {
+		return nil, fmt.Errorf("interpreter can not perform substitutions")
+	}
+	flags := word.SubstAll
+	for n, f := range map[string]int{
+		"nobackslashes": word.SubstBackslashes,
+		"nocommands":    word.SubstCommands,
+		"novariables":   word.SubstVariables,
+	} {
+		if v, _ := i.Resolve(n); v != nil && env.ToBool(v) {
+			flags &^= f
+		}
+	}
+	return s.Subst(slices.Fst(args).String(), flags)
+}
+
+func runApply(i Interpreter, args []env.Value) (env.Value, error) {
+	a, ok := i.(interface {
+		Apply(env.Value, []env.Value) (env.Value, error)
+	})
+	if !ok {
+		return nil, fmt.Errorf("interpreter can not apply anonymous function")
+	}
+	return a.Apply(slices.Fst(args), slices.Rest(args))
 }
 
 func runDefer(i Interpreter, args []env.Value) (env.Value, error) {
@@ -614,7 +723,23 @@ func runGlobal(i Interpreter, args []env.Value) (env.Value, error) {
 	if !ok {
 		return nil, fmt.Errorf("interpreter can not create link between variables")
 	}
-	return env.EmptyStr(), linkVars(k, args, 0)
+	level := k.Depth() - 1
+	if level == 0 {
+		return env.EmptyStr(), nil
+	}
+	for _, a := range args {
+		var (
+			src = strings.TrimPrefix(a.String(), "::")
+			dst = a.String()
+		)
+		if x := strings.LastIndex(dst, "::"); x >= 0 {
+			dst = dst[x+2:]
+		}
+		if err := k.LinkVar(src, dst, level); err != nil {
+			return nil, err
+		}
+	}
+	return env.EmptyStr(), nil
 }
 
 func runUpvar(i Interpreter, args []env.Value) (env.Value, error) {
@@ -652,14 +777,14 @@ func linkVars(k LinkHandler, args []env.Value, level int) error {
 func runLoop(i Interpreter, cdt, body, next env.Value) (env.Value, error) {
 	var res env.Value
 	for {
-		b, err := testScript(i, cdt)
+		b, err := testExpr(i, cdt)
 		if err != nil {
 			return nil, err
 		}
 		if !b {
 			break
 		}
-		res, err = i.Execute(strings.NewReader(body.String()))
+		res, err = executeValue(i, body)
 		if err != nil && !errors.Is(err, ErrContinue) {
 			if errors.Is(err, ErrBreak) {
 				err = nil
@@ -669,7 +794,7 @@ func runLoop(i Interpreter, cdt, body, next env.Value) (env.Value, error) {
 		if next == nil {
 			continue
 		}
-		_, err = i.Execute(strings.NewReader(next.String()))
+		_, err = executeValue(i, next)
 		if err != nil {
 			return nil, err
 		}
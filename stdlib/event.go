@@ -0,0 +1,164 @@
+package stdlib
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/midbel/gotcl/env"
+	"github.com/midbel/slices"
+)
+
+type EventHandler interface {
+	Interpreter
+
+	After(int, string) string
+	AfterIdle(string) string
+	AfterCancel(string)
+	AfterIds() []string
+	AfterInfo(string) (string, string, error)
+
+	Wait(string) error
+	Update(bool) error
+
+	FileEvent(string, string, string) error
+	GetFileEvent(string, string) (string, error)
+}
+
+type eventFunc func(EventHandler, []env.Value) (env.Value, error)
+
+func wrapEventFunc(do eventFunc) CommandFunc {
+	return func(i Interpreter, args []env.Value) (env.Value, error) {
+		ev, ok := i.(EventHandler)
+		if !ok {
+			return nil, fmt.Errorf("interpreter can not handle events")
+		}
+		return do(ev, args)
+	}
+}
+
+func RunAfter() Executer {
+	return Builtin{
+		Name:     "after",
+		Help:     "execute a command after a time delay",
+		Arity:    1,
+		Variadic: true,
+		Safe:     true,
+		Run:      wrapEventFunc(runAfter),
+	}
+}
+
+func RunVwait() Executer {
+	return Builtin{
+		Name:  "vwait",
+		Help:  "process events until a variable is written",
+		Arity: 1,
+		Safe:  true,
+		Run:   wrapEventFunc(runVwait),
+	}
+}
+
+func RunUpdate() Executer {
+	return Builtin{
+		Name:     "update",
+		Help:     "process pending events and idle callbacks",
+		Variadic: true,
+		Safe:     true,
+		Run:      wrapEventFunc(runUpdate),
+	}
+}
+
+func RunFileEvent() Executer {
+	return Builtin{
+		Name:     "fileevent",
+		Help:     "execute a script when a channel becomes readable or writable",
+		Arity:    2,
+		Variadic: true,
+		Safe:     true,
+		Run:      wrapEventFunc(runFileEvent),
+	}
+}
+
+func runAfter(ev EventHandler, args []env.Value) (env.Value, error) {
+	var (
+		cmd  = slices.Fst(args).String()
+		rest = slices.Rest(args)
+	)
+	switch cmd {
+	case "idle":
+		if len(rest) == 0 {
+			return nil, fmt.Errorf("after idle: %w", ErrArgument)
+		}
+		return env.Str(ev.AfterIdle(concatArgs(rest))), nil
+	case "cancel":
+		if len(rest) == 0 {
+			return nil, fmt.Errorf("after cancel: %w", ErrArgument)
+		}
+		ev.AfterCancel(concatArgs(rest))
+		return env.EmptyStr(), nil
+	case "info":
+		if len(rest) == 0 {
+			return env.ListFromStrings(ev.AfterIds()), nil
+		}
+		script, kind, err := ev.AfterInfo(slices.Fst(rest).String())
+		if err != nil {
+			return nil, err
+		}
+		return env.ListFrom(env.Str(script), env.Str(kind)), nil
+	default:
+	}
+	ms, err := env.ToInt(slices.Fst(args))
+	if err != nil {
+		return nil, fmt.Errorf("bad argument %q: must be cancel, idle, info, or an integer", cmd)
+	}
+	if ms < 0 {
+		ms = 0
+	}
+	if len(rest) == 0 {
+		time.Sleep(time.Duration(ms) * time.Millisecond)
+		return env.EmptyStr(), nil
+	}
+	return env.Str(ev.After(ms, concatArgs(rest))), nil
+}
+
+func runVwait(ev EventHandler, args []env.Value) (env.Value, error) {
+	return env.EmptyStr(), ev.Wait(slices.Fst(args).String())
+}
+
+func runUpdate(ev EventHandler, args []env.Value) (env.Value, error) {
+	var idle bool
+	if v := slices.Fst(args); v != nil {
+		if v.String() != "idletasks" {
+			return nil, fmt.Errorf("bad option %q: must be idletasks", v.String())
+		}
+		idle = true
+	}
+	return env.EmptyStr(), ev.Update(idle)
+}
+
+func runFileEvent(ev EventHandler, args []env.Value) (env.Value, error) {
+	var (
+		fd   = slices.Fst(args).String()
+		kind = slices.Snd(args).String()
+	)
+	if v := slices.At(args, 2); v != nil {
+		return env.EmptyStr(), ev.FileEvent(fd, kind, v.String())
+	}
+	script, err := ev.GetFileEvent(fd, kind)
+	if err != nil {
+		return nil, err
+	}
+	return env.Str(script), nil
+}
+
+func concatArgs(args []env.Value) string {
+	var list []string
+	for _, a := range args {
+		str := strings.TrimSpace(a.String())
+		if str == "" {
+			continue
+		}
+		list = append(list, str)
+	}
+	return strings.Join(list, " ")
+}
@@ -0,0 +1,548 @@
+package stdlib
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"syscall"
+
+	"github.com/midbel/gotcl/env"
+	"github.com/midbel/slices"
+)
+
+func RunExec() Executer {
+	return Builtin{
+		Name:     "exec",
+		Help:     "invoke subprocesses",
+		Arity:    1,
+		Variadic: true,
+		Safe:     false,
+		Options: []Option{
+			{
+				Name:  "ignorestderr",
+				Flag:  true,
+				Value: env.False(),
+				Check: CheckBool,
+			},
+			{
+				Name:  "keepnewline",
+				Flag:  true,
+				Value: env.False(),
+				Check: CheckBool,
+			},
+		},
+		Run: runExec,
+	}
+}
+
+func runExec(i Interpreter, args []env.Value) (env.Value, error) {
+	var (
+		ignore, _ = i.Resolve("ignorestderr")
+		keep, _   = i.Resolve("keepnewline")
+	)
+	p, err := parsePipeline(i, args)
+	if err != nil {
+		return nil, err
+	}
+	if p.background {
+		return p.spawn()
+	}
+	defer p.close()
+
+	var (
+		outbuf lockedBuffer
+		errbuf lockedBuffer
+		stdout = p.stdout
+		stderr = p.stderr
+	)
+	if stdout == nil {
+		stdout = &outbuf
+	}
+	if stderr == nil {
+		stderr = &errbuf
+		if env.ToBool(ignore) {
+			stderr = os.Stderr
+		}
+	}
+	if p.merge {
+		stderr = stdout
+	}
+	cmds, err := p.start(stdout, stderr)
+	if err != nil {
+		return nil, err
+	}
+	var failure error
+	for _, c := range cmds {
+		if err := c.Wait(); err != nil && failure == nil {
+			failure = err
+		}
+	}
+	out := outbuf.String()
+	if !env.ToBool(keep) {
+		out = strings.TrimSuffix(out, "\n")
+	}
+	msg := out
+	if errbuf.Len() > 0 {
+		if msg != "" {
+			msg += "\n"
+		}
+		msg += strings.TrimSuffix(errbuf.String(), "\n")
+	}
+	if err := commandError(failure, msg, errbuf.Len() > 0); err != nil {
+		return nil, err
+	}
+	return env.Str(out), nil
+}
+
+// commandError reports a failed child or, when all children succeeded, the
+// output they wrote on their standard error.
+func commandError(failure error, msg string, stderr bool) error {
+	if failure != nil {
+		return childError(failure, msg)
+	}
+	if stderr {
+		return Error{
+			Err:       errors.New(msg),
+			Code:      ErrorErr,
+			ErrorCode: env.ListFrom(env.Str("NONE")),
+		}
+	}
+	return nil
+}
+
+func childError(err error, msg string) error {
+	var ee *exec.ExitError
+	if !errors.As(err, &ee) {
+		return ErrorFromError(err)
+	}
+	var (
+		pid  = env.Int(int64(ee.Pid()))
+		code = env.ListFrom(env.Str("CHILDSTATUS"), pid, env.Int(int64(ee.ExitCode())))
+	)
+	if ws, ok := ee.Sys().(syscall.WaitStatus); ok && ws.Signaled() {
+		sig := ws.Signal().String()
+		code = env.ListFrom(env.Str("CHILDKILLED"), pid, env.Str(sig))
+		if msg == "" {
+			msg = fmt.Sprintf("child killed: %s", sig)
+		}
+	}
+	if msg == "" {
+		msg = "child process exited abnormally"
+	}
+	return Error{
+		Err:       errors.New(msg),
+		Code:      ErrorErr,
+		ErrorCode: code,
+	}
+}
+
+type pipeline struct {
+	cmds [][]string
+	both []bool
+
+	stdin  io.Reader
+	stdout io.Writer
+	stderr io.Writer
+	merge  bool
+
+	background bool
+	files      []io.Closer
+}
+
+var redirections = []string{
+	"2>@1",
+	"2>>",
+	"2>@",
+	"2>",
+	">>&",
+	">&@",
+	">&",
+	">>",
+	">@",
+	">",
+	"<<",
+	"<@",
+	"<",
+	"|&",
+	"|",
+}
+
+func splitRedirection(str string) (string, string) {
+	if str == "2>@1" {
+		return str, ""
+	}
+	for _, r := range slices.Rest(redirections) {
+		if strings.HasPrefix(str, r) {
+			return r, str[len(r):]
+		}
+	}
+	return "", str
+}
+
+func parsePipeline(i Interpreter, args []env.Value) (*pipeline, error) {
+	p := pipeline{
+		stdin: os.Stdin,
+	}
+	if n := len(args); n > 0 && slices.Lst(args).String() == "&" {
+		p.background = true
+		args = slices.Slice(args)
+	}
+	var curr []string
+	for j := 0; j < len(args); j++ {
+		op, target := splitRedirection(args[j].String())
+		if op == "" {
+			curr = append(curr, target)
+			continue
+		}
+		switch op {
+		case "|", "|&":
+			if len(curr) == 0 {
+				p.close()
+				return nil, fmt.Errorf("illegal use of | or |& in command")
+			}
+			p.cmds = append(p.cmds, curr)
+			p.both = append(p.both, op == "|&")
+			curr = nil
+			continue
+		case "2>@1":
+			p.merge = true
+			continue
+		default:
+		}
+		if target == "" {
+			if j+1 >= len(args) {
+				p.close()
+				return nil, fmt.Errorf("can't specify %q as last word in command", op)
+			}
+			j++
+			target = args[j].String()
+		}
+		if err := p.redirect(i, op, target); err != nil {
+			p.close()
+			return nil, err
+		}
+	}
+	if len(curr) == 0 {
+		p.close()
+		if len(p.cmds) > 0 {
+			return nil, fmt.Errorf("illegal use of | or |& in command")
+		}
+		return nil, fmt.Errorf("didn't specify command to execute")
+	}
+	p.cmds = append(p.cmds, curr)
+	return &p, nil
+}
+
+func (p *pipeline) redirect(i Interpreter, op, target string) error {
+	switch op {
+	case "<":
+		f, err := os.Open(target)
+		if err != nil {
+			return fmt.Errorf("couldn't read file %q: %w", target, err)
+		}
+		p.files = append(p.files, f)
+		p.stdin = f
+		return nil
+	case "<<":
+		p.stdin = strings.NewReader(target)
+		return nil
+	case "<@":
+		x, ok := i.(interface {
+			Reader(string) (io.Reader, error)
+		})
+		if !ok {
+			return fmt.Errorf("interpreter can not handle channels")
+		}
+		r, err := x.Reader(target)
+		if err != nil {
+			return err
+		}
+		p.stdin = r
+		return nil
+	default:
+	}
+	var w io.Writer
+	switch op {
+	case ">", "2>", ">&":
+		f, err := os.Create(target)
+		if err != nil {
+			return fmt.Errorf("couldn't write file %q: %w", target, err)
+		}
+		p.files = append(p.files, f)
+		w = f
+	case ">>", "2>>", ">>&":
+		f, err := os.OpenFile(target, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+		if err != nil {
+			return fmt.Errorf("couldn't write file %q: %w", target, err)
+		}
+		p.files = append(p.files, f)
+		w = f
+	case ">@", "2>@", ">&@":
+		x, ok := i.(interface {
+			Writer(string) (io.Writer, error)
+		})
+		if !ok {
+			return fmt.Errorf("interpreter can not handle channels")
+		}
+		c, err := x.Writer(target)
+		if err != nil {
+			return err
+		}
+		w = c
+	default:
+		return fmt.Errorf("%s: unknown redirection", op)
+	}
+	switch {
+	case strings.HasPrefix(op, "2"):
+		p.stderr = w
+	case strings.Contains(op, "&"):
+		p.stdout, p.stderr = w, w
+	default:
+		p.stdout = w
+	}
+	return nil
+}
+
+func (p *pipeline) start(stdout, stderr io.Writer) ([]*exec.Cmd, error) {
+	var (
+		list  []*exec.Cmd
+		stdin = p.stdin
+		pipes []io.Closer
+	)
+	defer func() {
+		for _, c := range pipes {
+			c.Close()
+		}
+	}()
+	for j, args := range p.cmds {
+		cmd := exec.Command(slices.Fst(args), slices.Rest(args)...)
+		cmd.Stdin = stdin
+		cmd.Stdout = stdout
+		cmd.Stderr = stderr
+		if j < len(p.cmds)-1 {
+			r, w, err := os.Pipe()
+			if err != nil {
+				reapCommands(list)
+				return nil, err
+			}
+			pipes = append(pipes, r, w)
+			cmd.Stdout = w
+			if p.both[j] {
+				cmd.Stderr = w
+			}
+			stdin = r
+		}
+		if err := cmd.Start(); err != nil {
+			reapCommands(list)
+			return nil, fmt.Errorf("couldn't execute %q: %w", slices.Fst(args), err)
+		}
+		list = append(list, cmd)
+	}
+	return list, nil
+}
+
+func (p *pipeline) spawn() (env.Value, error) {
+	var (
+		stdout = p.stdout
+		stderr = p.stderr
+	)
+	if stdout == nil {
+		stdout = os.Stdout
+	}
+	if stderr == nil {
+		stderr = os.Stderr
+	}
+	if p.merge {
+		stderr = stdout
+	}
+	cmds, err := p.start(stdout, stderr)
+	if err != nil {
+		p.close()
+		return nil, err
+	}
+	var pids []env.Value
+	for _, c := range cmds {
+		pids = append(pids, env.Int(int64(c.Process.Pid)))
+	}
+	go func() {
+		for _, c := range cmds {
+			c.Wait()
+		}
+		p.close()
+	}()
+	return env.ListFrom(pids...), nil
+}
+
+func (p *pipeline) open(mode string) (*commandChannel, error) {
+	var (
+		ch     commandChannel
+		stdout = p.stdout
+		stderr = p.stderr
+		ends   []io.Closer
+		read   bool
+		write  bool
+	)
+	switch mode {
+	case "", "r":
+		read = true
+	case "w":
+		write = true
+	case "r+", "w+":
+		read, write = true, true
+	default:
+		return nil, fmt.Errorf("%s: unknown mode given", mode)
+	}
+	defer func() {
+		for _, c := range ends {
+			c.Close()
+		}
+	}()
+	if read {
+		if stdout != nil {
+			return nil, fmt.Errorf("can't read output from command: standard output was redirected")
+		}
+		r, w, err := os.Pipe()
+		if err != nil {
+			return nil, err
+		}
+		ends = append(ends, w)
+		ch.stdout = r
+		stdout = w
+	} else if stdout == nil {
+		stdout = os.Stdout
+	}
+	if write {
+		if p.stdin != io.Reader(os.Stdin) {
+			ch.closeEnds()
+			return nil, fmt.Errorf("can't write input to command: standard input was redirected")
+		}
+		r, w, err := os.Pipe()
+		if err != nil {
+			ch.closeEnds()
+			return nil, err
+		}
+		ends = append(ends, r)
+		ch.stdin = w
+		p.stdin = r
+	}
+	if stderr == nil {
+		ch.stderr = new(lockedBuffer)
+		stderr = ch.stderr
+	}
+	if p.merge {
+		ch.stderr = nil
+		stderr = stdout
+	}
+	cmds, err := p.start(stdout, stderr)
+	if err != nil {
+		ch.closeEnds()
+		return nil, err
+	}
+	ch.cmds = cmds
+	ch.done = p.close
+	return &ch, nil
+}
+
+func (p *pipeline) close() {
+	for _, f := range p.files {
+		f.Close()
+	}
+	p.files = nil
+}
+
+func reapCommands(list []*exec.Cmd) {
+	for _, c := range list {
+		go c.Wait()
+	}
+}
+
+type lockedBuffer struct {
+	mu sync.Mutex
+	bytes.Buffer
+}
+
+func (b *lockedBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.Buffer.Write(p)
+}
+
+type commandChannel struct {
+	cmds   []*exec.Cmd
+	stdin  io.WriteCloser
+	stdout *os.File
+	stderr *lockedBuffer
+	done   func()
+}
+
+func (c *commandChannel) Read(b []byte) (int, error) {
+	if c.stdout == nil {
+		return 0, fmt.Errorf("channel wasn't opened for reading")
+	}
+	return c.stdout.Read(b)
+}
+
+func (c *commandChannel) Write(b []byte) (int, error) {
+	if c.stdin == nil {
+		return 0, fmt.Errorf("channel wasn't opened for writing")
+	}
+	return c.stdin.Write(b)
+}
+
+func (c *commandChannel) Pids() []int {
+	var list []int
+	for _, x := range c.cmds {
+		list = append(list, x.Process.Pid)
+	}
+	return list
+}
+
+func (c *commandChannel) Close() error {
+	c.closeEnds()
+	var failure error
+	for _, x := range c.cmds {
+		if err := x.Wait(); err != nil && failure == nil {
+			failure = err
+		}
+	}
+	if c.done != nil {
+		c.done()
+	}
+	var msg string
+	if c.stderr != nil {
+		msg = strings.TrimSuffix(c.stderr.String(), "\n")
+	}
+	return commandError(failure, msg, msg != "")
+}
+
+func (c *commandChannel) CloseRead() error {
+	if c.stdout == nil {
+		return fmt.Errorf("channel wasn't opened for reading")
+	}
+	err := c.stdout.Close()
+	c.stdout = nil
+	return err
+}
+
+func (c *commandChannel) CloseWrite() error {
+	if c.stdin == nil {
+		return fmt.Errorf("channel wasn't opened for writing")
+	}
+	err := c.stdin.Close()
+	c.stdin = nil
+	return err
+}
+
+func (c *commandChannel) closeEnds() {
+	if c.stdin != nil {
+		c.stdin.Close()
+	}
+	if c.stdout != nil {
+		c.stdout.Close()
+	}
+}
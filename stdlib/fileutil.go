@@ -3,7 +3,6 @@ package stdlib
 import (
 	"bufio"
 	"os"
-	"strings"
 
 	"github.com/midbel/gotcl/env"
 	"github.com/midbel/slices"
@@ -58,7 +57,7 @@ func fileutilForeachLine(i Interpreter, args []env.Value) (env.Value, error) {
 	)
 	for scan.Scan() {
 		i.Define(slices.Snd(args).String(), env.Str(scan.Text()))
-		res, err = i.Execute(strings.NewReader(slices.Lst(args).String()))
+		res, err = executeValue(i, slices.Lst(args))
 		if err != nil {
 			break
 		}
@@ -0,0 +1,630 @@
+package stdlib
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/midbel/gotcl/env"
+	"github.com/midbel/slices"
+)
+
+var ErrFormat = errors.New("bad field specifier")
+
+func RunFormat() Executer {
+	return Builtin{
+		Name:     "format",
+		Help:     "format a string in the style of sprintf",
+		Arity:    1,
+		Variadic: true,
+		Safe:     true,
+		Run:      runFormat,
+	}
+}
+
+func RunScan() Executer {
+	return Builtin{
+		Name:     "scan",
+		Help:     "parse string using conversion specifiers in the style of sscanf",
+		Arity:    2,
+		Variadic: true,
+		Safe:     true,
+		Run:      runScan,
+	}
+}
+
+func runFormat(i Interpreter, args []env.Value) (env.Value, error) {
+	str, err := formatString(slices.Fst(args).String(), slices.Rest(args))
+	if err != nil {
+		return nil, err
+	}
+	return env.Str(str), nil
+}
+
+func runScan(i Interpreter, args []env.Value) (env.Value, error) {
+	var (
+		input = slices.Fst(args).String()
+		pat   = slices.Snd(args).String()
+		vars  = slices.Take(args, 2)
+	)
+	values, count, err := scanString(input, pat)
+	if err != nil {
+		return nil, err
+	}
+	if len(vars) == 0 {
+		if count < 0 {
+			return env.Int(-1), nil
+		}
+		list := make([]env.Value, len(values))
+		for j := range values {
+			list[j] = values[j]
+			if list[j] == nil {
+				list[j] = env.EmptyStr()
+			}
+		}
+		return env.ListFrom(list...), nil
+	}
+	if len(vars) != len(values) {
+		return nil, fmt.Errorf("different numbers of variable names and field specifiers")
+	}
+	for j, v := range vars {
+		if values[j] == nil {
+			continue
+		}
+		i.Define(v.String(), values[j])
+	}
+	return env.Int(int64(count)), nil
+}
+
+type fieldSpec struct {
+	pos      int
+	flags    string
+	width    int
+	prec     int
+	size     string
+	verb     byte
+	suppress bool
+	charset  string
+}
+
+const (
+	noValue  = -1
+	argValue = -2
+)
+
+func parseFieldSpec(str string, scan bool) (fieldSpec, int, error) {
+	spec := fieldSpec{
+		width: noValue,
+		prec:  noValue,
+	}
+	var j int
+	digits := func() (int, bool) {
+		k := j
+		for j < len(str) && isDigit(str[j]) {
+			j++
+		}
+		if k == j {
+			return 0, false
+		}
+		n, _ := strconv.Atoi(str[k:j])
+		return n, true
+	}
+	if n, ok := digits(); ok && j < len(str) && str[j] == '$' {
+		if n == 0 {
+			return spec, 0, fmt.Errorf("%w: invalid position", ErrFormat)
+		}
+		spec.pos = n
+		j++
+	} else {
+		j = 0
+	}
+	if scan {
+		if j < len(str) && str[j] == '*' {
+			spec.suppress = true
+			j++
+		}
+	} else {
+		for j < len(str) && strings.IndexByte("-+ 0#", str[j]) >= 0 {
+			spec.flags += string(str[j])
+			j++
+		}
+	}
+	if !scan && j < len(str) && str[j] == '*' {
+		spec.width = argValue
+		j++
+	} else if n, ok := digits(); ok {
+		spec.width = n
+	}
+	if !scan && j < len(str) && str[j] == '.' {
+		j++
+		if j < len(str) && str[j] == '*' {
+			spec.prec = argValue
+			j++
+		} else {
+			n, _ := digits()
+			spec.prec = n
+		}
+	}
+	for _, z := range []string{"ll", "h", "l", "L", "j", "z", "t", "q"} {
+		if strings.HasPrefix(str[j:], z) {
+			spec.size = z
+			j += len(z)
+			break
+		}
+	}
+	if j >= len(str) {
+		return spec, j, fmt.Errorf("%w: format string ended in middle of field specifier", ErrFormat)
+	}
+	spec.verb = str[j]
+	j++
+	if scan && spec.verb == '[' {
+		k := j
+		if j < len(str) && str[j] == '^' {
+			j++
+		}
+		if j < len(str) && str[j] == ']' {
+			j++
+		}
+		for j < len(str) && str[j] != ']' {
+			j++
+		}
+		if j >= len(str) {
+			return spec, j, fmt.Errorf("%w: unmatched [ in format string", ErrFormat)
+		}
+		spec.charset = str[k:j]
+		j++
+	}
+	return spec, j, nil
+}
+
+func formatString(pat string, args []env.Value) (string, error) {
+	var (
+		buf  strings.Builder
+		next int
+		xpg  = noValue
+	)
+	getArg := func(pos int) (env.Value, error) {
+		if pos > 0 {
+			if pos > len(args) {
+				return nil, fmt.Errorf("\"%%n$\" argument index out of range")
+			}
+			return args[pos-1], nil
+		}
+		if next >= len(args) {
+			return nil, fmt.Errorf("not enough arguments for all format specifiers")
+		}
+		next++
+		return args[next-1], nil
+	}
+	for j := 0; j < len(pat); {
+		if pat[j] != '%' {
+			buf.WriteByte(pat[j])
+			j++
+			continue
+		}
+		spec, n, err := parseFieldSpec(pat[j+1:], false)
+		if err != nil {
+			return "", err
+		}
+		j += n + 1
+		if spec.verb == '%' {
+			buf.WriteByte('%')
+			continue
+		}
+		positional := spec.pos > 0
+		if xpg == noValue {
+			xpg = 0
+			if positional {
+				xpg = 1
+			}
+		} else if positional != (xpg == 1) {
+			return "", fmt.Errorf("cannot mix \"%%\" and \"%%n$\" conversion specifiers")
+		}
+		if spec.width == argValue || spec.prec == argValue {
+			if positional {
+				return "", fmt.Errorf("cannot use \"*\" with \"%%n$\" conversion specifiers")
+			}
+			if spec.width == argValue {
+				v, err := getArg(0)
+				if err != nil {
+					return "", err
+				}
+				w, err := toInteger(v)
+				if err != nil {
+					return "", err
+				}
+				if w < 0 {
+					spec.flags += "-"
+					w = -w
+				}
+				spec.width = int(w)
+			}
+			if spec.prec == argValue {
+				v, err := getArg(0)
+				if err != nil {
+					return "", err
+				}
+				p, err := toInteger(v)
+				if err != nil {
+					return "", err
+				}
+				spec.prec = int(p)
+			}
+		}
+		arg, err := getArg(spec.pos)
+		if err != nil {
+			return "", err
+		}
+		str, err := formatField(spec, arg)
+		if err != nil {
+			return "", err
+		}
+		buf.WriteString(str)
+	}
+	return buf.String(), nil
+}
+
+func formatField(spec fieldSpec, arg env.Value) (string, error) {
+	var layout strings.Builder
+	layout.WriteByte('%')
+	layout.WriteString(spec.flags)
+	if spec.width >= 0 {
+		layout.WriteString(strconv.Itoa(spec.width))
+	}
+	if spec.prec >= 0 {
+		layout.WriteByte('.')
+		layout.WriteString(strconv.Itoa(spec.prec))
+	}
+	switch spec.verb {
+	case 'd', 'i':
+		n, err := toBigInteger(arg)
+		if err != nil {
+			return "", err
+		}
+		layout.WriteByte('d')
+		if spec.size == "ll" {
+			return fmt.Sprintf(layout.String(), n), nil
+		}
+		return fmt.Sprintf(layout.String(), truncInteger(wideInteger(n), spec.size)), nil
+	case 'u', 'o', 'x', 'X', 'b':
+		n, err := toBigInteger(arg)
+		if err != nil {
+			return "", err
+		}
+		verb := spec.verb
+		if verb == 'u' {
+			verb = 'd'
+		}
+		layout.WriteByte(verb)
+		if spec.size == "ll" && n.Sign() >= 0 {
+			return fmt.Sprintf(layout.String(), n), nil
+		}
+		return fmt.Sprintf(layout.String(), truncUnsigned(wideInteger(n), spec.size)), nil
+	case 'c':
+		n, err := toInteger(arg)
+		if err != nil {
+			return "", err
+		}
+		layout.WriteByte('s')
+		return fmt.Sprintf(layout.String(), string(rune(n))), nil
+	case 's':
+		layout.WriteByte('s')
+		return fmt.Sprintf(layout.String(), arg.String()), nil
+	case 'f', 'e', 'E', 'g', 'G':
+		f, err := env.ToFloat(arg)
+		if err != nil {
+			return "", fmt.Errorf("expected floating-point number but got %q", arg.String())
+		}
+		layout.WriteByte(spec.verb)
+		return fmt.Sprintf(layout.String(), f), nil
+	default:
+		return "", fmt.Errorf("%w: bad field specifier %q", ErrFormat, spec.verb)
+	}
+}
+
+func toInteger(v env.Value) (int64, error) {
+	n, err := toBigInteger(v)
+	if err != nil {
+		return 0, err
+	}
+	return wideInteger(n), nil
+}
+
+// toBigInteger accepts integers of any size and reals without fractional
+// part.
+func toBigInteger(v env.Value) (*big.Int, error) {
+	str := strings.TrimSpace(v.String())
+	x, err := env.ParseNumber(str)
+	if err != nil {
+		return nil, fmt.Errorf("expected integer but got %q", str)
+	}
+	n, ok := x.(env.Number)
+	if !ok {
+		return nil, fmt.Errorf("expected integer but got %q", str)
+	}
+	if !n.IsInt() {
+		f, _ := env.ToFloat(n)
+		if math.IsInf(f, 0) || math.IsNaN(f) || f != math.Trunc(f) {
+			return nil, fmt.Errorf("expected integer but got %q", str)
+		}
+	}
+	return n.Big(), nil
+}
+
+func truncInteger(n int64, size string) int64 {
+	switch size {
+	case "h":
+		return int64(int16(n))
+	case "":
+		return int64(int32(n))
+	default:
+		return n
+	}
+}
+
+func truncUnsigned(n int64, size string) uint64 {
+	switch size {
+	case "h":
+		return uint64(uint16(n))
+	case "":
+		return uint64(uint32(n))
+	default:
+		return uint64(n)
+	}
+}
+
+func scanString(input, pat string) ([]env.Value, int, error) {
+	var (
+		values    []env.Value
+		count     int
+		next      int
+		offset    int
+		done      bool
+		underflow bool
+		str       = []rune(input)
+	)
+	assign := func(spec fieldSpec, v env.Value) {
+		if spec.suppress {
+			return
+		}
+		ix := next
+		if spec.pos > 0 {
+			ix = spec.pos - 1
+		} else {
+			next++
+		}
+		for len(values) <= ix {
+			values = append(values, nil)
+		}
+		if v != nil {
+			values[ix] = v
+		}
+	}
+	skipBlanks := func() {
+		for offset < len(str) && unicode.IsSpace(str[offset]) {
+			offset++
+		}
+	}
+	for j := 0; j < len(pat); {
+		c, z := utf8.DecodeRuneInString(pat[j:])
+		if unicode.IsSpace(c) {
+			skipBlanks()
+			j += z
+			continue
+		}
+		if c != '%' {
+			if !done && (offset >= len(str) || str[offset] != c) {
+				done, underflow = true, offset >= len(str)
+			}
+			if !done {
+				offset++
+			}
+			j += z
+			continue
+		}
+		spec, n, err := parseFieldSpec(pat[j+1:], true)
+		if err != nil {
+			return nil, 0, err
+		}
+		j += n + 1
+		if spec.verb == '%' {
+			if !done && (offset >= len(str) || str[offset] != '%') {
+				done, underflow = true, offset >= len(str)
+			}
+			if !done {
+				offset++
+			}
+			continue
+		}
+		if strings.IndexByte("diuoxXbcsfeEgG[n", spec.verb) < 0 {
+			return nil, 0, fmt.Errorf("%w: bad scan conversion character %q", ErrFormat, spec.verb)
+		}
+		if done {
+			assign(spec, nil)
+			continue
+		}
+		if spec.verb == 'n' {
+			assign(spec, env.Int(int64(offset)))
+			continue
+		}
+		if spec.verb != 'c' && spec.verb != '[' {
+			skipBlanks()
+		}
+		if offset >= len(str) {
+			done, underflow = true, true
+			assign(spec, nil)
+			continue
+		}
+		width := len(str) - offset
+		if spec.width > 0 && spec.width < width {
+			width = spec.width
+		}
+		val, size, err := scanField(spec, str[offset:offset+width])
+		if err != nil || size == 0 {
+			done = true
+			assign(spec, nil)
+			continue
+		}
+		offset += size
+		if !spec.suppress {
+			count++
+		}
+		assign(spec, val)
+	}
+	if underflow && count == 0 {
+		count = -1
+	}
+	return values, count, nil
+}
+
+func scanField(spec fieldSpec, str []rune) (env.Value, int, error) {
+	switch spec.verb {
+	case 'c':
+		return env.Int(int64(str[0])), 1, nil
+	case 's':
+		var j int
+		for j < len(str) && !unicode.IsSpace(str[j]) {
+			j++
+		}
+		return env.Str(string(str[:j])), j, nil
+	case '[':
+		var j int
+		for j < len(str) && matchCharset(spec.charset, str[j]) {
+			j++
+		}
+		return env.Str(string(str[:j])), j, nil
+	case 'd', 'u':
+		return scanInteger(str, 10)
+	case 'o':
+		return scanInteger(str, 8)
+	case 'x', 'X':
+		return scanInteger(str, 16)
+	case 'b':
+		return scanInteger(str, 2)
+	case 'i':
+		return scanInteger(str, 0)
+	default:
+		return scanFloat(str)
+	}
+}
+
+func scanInteger(str []rune, base int) (env.Value, int, error) {
+	var j int
+	if j < len(str) && (str[j] == '-' || str[j] == '+') {
+		j++
+	}
+	if base == 0 {
+		base = 10
+		if j < len(str) && str[j] == '0' {
+			base = 8
+			if j+1 < len(str) && (str[j+1] == 'x' || str[j+1] == 'X') {
+				base = 16
+				j += 2
+			}
+		}
+	} else if base == 16 && j+1 < len(str) && str[j] == '0' && (str[j+1] == 'x' || str[j+1] == 'X') {
+		j += 2
+	}
+	k := j
+	for j < len(str) && isBaseDigit(str[j], base) {
+		j++
+	}
+	if k == j {
+		return nil, 0, ErrFormat
+	}
+	digits := string(str[:k])
+	digits = strings.TrimSuffix(strings.TrimSuffix(digits, "0x"), "0X") + string(str[k:j])
+	n, err := strconv.ParseInt(digits, base, 64)
+	if err != nil {
+		return nil, 0, err
+	}
+	return env.Int(n), j, nil
+}
+
+func scanFloat(str []rune) (env.Value, int, error) {
+	var j int
+	if j < len(str) && (str[j] == '-' || str[j] == '+') {
+		j++
+	}
+	k := j
+	for j < len(str) && isDigit(byte(str[j])) && str[j] < utf8.RuneSelf {
+		j++
+	}
+	if j < len(str) && str[j] == '.' {
+		j++
+		for j < len(str) && str[j] < utf8.RuneSelf && isDigit(byte(str[j])) {
+			j++
+		}
+	}
+	if j == k || (j == k+1 && str[k] == '.') {
+		return nil, 0, ErrFormat
+	}
+	if j < len(str) && (str[j] == 'e' || str[j] == 'E') {
+		e := j + 1
+		if e < len(str) && (str[e] == '-' || str[e] == '+') {
+			e++
+		}
+		if e < len(str) && str[e] < utf8.RuneSelf && isDigit(byte(str[e])) {
+			for e < len(str) && str[e] < utf8.RuneSelf && isDigit(byte(str[e])) {
+				e++
+			}
+			j = e
+		}
+	}
+	f, err := strconv.ParseFloat(string(str[:j]), 64)
+	if err != nil {
+		return nil, 0, err
+	}
+	return env.Float(f), j, nil
+}
+
+func matchCharset(set string, c rune) bool {
+	var (
+		chars  = []rune(set)
+		negate bool
+		found  bool
+	)
+	if len(chars) > 0 && chars[0] == '^' {
+		negate = true
+		chars = chars[1:]
+	}
+	for j := 0; j < len(chars); j++ {
+		if j+2 < len(chars) && chars[j+1] == '-' {
+			if c >= chars[j] && c <= chars[j+2] {
+				found = true
+				break
+			}
+			j += 2
+			continue
+		}
+		if chars[j] == c {
+			found = true
+			break
+		}
+	}
+	return found != negate
+}
+
+func isBaseDigit(c rune, base int) bool {
+	var n int
+	switch {
+	case c >= '0' && c <= '9':
+		n = int(c - '0')
+	case c >= 'a' && c <= 'z':
+		n = int(c-'a') + 10
+	case c >= 'A' && c <= 'Z':
+		n = int(c-'A') + 10
+	default:
+		return false
+	}
+	return n < base
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
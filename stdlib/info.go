@@ -13,6 +13,8 @@ type CommandHandler interface {
 	Count() int
 	Commands(string) []string
 	CurrentFrame(int) (string, []string, error)
+	FrameDepth() int
+	FrameInfo(int) (env.Value, error)
 }
 
 type commandHandlerFunc func(CommandHandler, []env.Value) (env.Value, error)
@@ -88,6 +90,10 @@ func MakeInfo() Executer {
 				Name: "commands",
 				Run:  wrapCommandHandler(infoCommands),
 			},
+			Builtin{
+				Name: "coroutine",
+				Run:  wrapCoroutineFunc(infoCoroutine),
+			},
 			Builtin{
 				Name: "cmdcount",
 				Run:  wrapCommandHandler(infoCommandCount),
@@ -97,6 +103,11 @@ func MakeInfo() Executer {
 				Variadic: true,
 				Run:      wrapCommandHandler(infoCommandLevel),
 			},
+			Builtin{
+				Name:     "frame",
+				Variadic: true,
+				Run:      wrapCommandHandler(infoCommandFrame),
+			},
 			Builtin{
 				Name:     "procs",
 				Variadic: true,
@@ -184,7 +195,7 @@ func infoCommandCount(ch CommandHandler, args []env.Value) (env.Value, error) {
 
 func infoCommandLevel(ch CommandHandler, args []env.Value) (env.Value, error) {
 	if len(args) == 0 {
-		d := ch.Depth()
+		d := ch.Depth() - 1
 		return env.Int(int64(d)), nil
 	}
 	n, err := env.ToInt(slices.Fst(args))
@@ -198,6 +209,18 @@ func infoCommandLevel(ch CommandHandler, args []env.Value) (env.Value, error) {
 	return env.ListFromStrings(slices.Prepend(cmd, params)), nil
 }
 
+func infoCommandFrame(ch CommandHandler, args []env.Value) (env.Value, error) {
+	if len(args) == 0 {
+		d := ch.FrameDepth()
+		return env.Int(int64(d)), nil
+	}
+	n, err := env.ToInt(slices.Fst(args))
+	if err != nil {
+		return nil, err
+	}
+	return ch.FrameInfo(n)
+}
+
 func infoProcedures(ph ProcHandler, args []env.Value) (env.Value, error) {
 	pat := slices.Fst(args)
 	if pat == nil {
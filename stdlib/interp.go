@@ -2,7 +2,6 @@ package stdlib
 
 import (
 	"fmt"
-	"strings"
 
 	"github.com/midbel/gotcl/env"
 	"github.com/midbel/slices"
@@ -103,7 +102,7 @@ func interpEval(i InterpHandler, args []env.Value) (env.Value, error) {
 	if err != nil {
 		return nil, err
 	}
-	return i.Execute(strings.NewReader(slices.Snd(args).String()))
+	return executeValue(i, slices.Snd(args))
 }
 
 func interpChildren(i InterpHandler, args []env.Value) (env.Value, error) {
@@ -1,9 +1,11 @@
 package stdlib
 
 import (
+	"errors"
 	"fmt"
 	"io"
 	"strings"
+	"unicode/utf8"
 
 	"github.com/midbel/gotcl/env"
 	"github.com/midbel/gotcl/glob"
@@ -31,6 +33,13 @@ type ChannelHandler interface {
 
 	Copy(string, string, int) (int64, error)
 
+	Flush(string) error
+	Blocked(string) (bool, error)
+
+	ConfigNames(string) ([]string, error)
+	Config(string, string) ([]string, error)
+	Configure(string, string, []string) error
+
 	PrintHandler
 }
 
@@ -52,9 +61,26 @@ func MakeChan() Executer {
 		Safe: true,
 		List: []Executer{
 			Builtin{
-				Name:  "close",
+				Name:  "blocked",
 				Arity: 1,
-				Run:   wrapChannelFunc(chanClose),
+				Run:   wrapChannelFunc(chanBlocked),
+			},
+			Builtin{
+				Name:     "configure",
+				Arity:    1,
+				Variadic: true,
+				Run:      wrapChannelFunc(chanConfigure),
+			},
+			Builtin{
+				Name:  "flush",
+				Arity: 1,
+				Run:   wrapChannelFunc(chanFlush),
+			},
+			Builtin{
+				Name:     "close",
+				Arity:    1,
+				Variadic: true,
+				Run:      wrapChannelFunc(chanClose),
 			},
 			Builtin{
 				Name:  "copy",
@@ -73,6 +99,12 @@ func MakeChan() Executer {
 				Arity: 1,
 				Run:   wrapChannelFunc(chanEof),
 			},
+			Builtin{
+				Name:     "event",
+				Arity:    2,
+				Variadic: true,
+				Run:      wrapEventFunc(runFileEvent),
+			},
 			Builtin{
 				Name:     "gets",
 				Arity:    1,
@@ -85,8 +117,9 @@ func MakeChan() Executer {
 				Run:      wrapChannelFunc(chanNames),
 			},
 			Builtin{
-				Name:  "puts",
-				Arity: 1,
+				Name:     "puts",
+				Arity:    1,
+				Variadic: true,
 				Options: []Option{
 					{
 						Name:  "nonewline",
@@ -150,10 +183,11 @@ func RunFCopy() Executer {
 
 func RunPuts() Executer {
 	return Builtin{
-		Name:  "puts",
-		Help:  "print a message to given channel (default to stdout)",
-		Arity: 1,
-		Safe:  true,
+		Name:     "puts",
+		Help:     "print a message to given channel (default to stdout)",
+		Arity:    1,
+		Variadic: true,
+		Safe:     true,
 		Options: []Option{
 			{
 				Name:  "nonewline",
@@ -172,6 +206,37 @@ func RunPuts() Executer {
 	}
 }
 
+func RunFConfigure() Executer {
+	return Builtin{
+		Name:     "fconfigure",
+		Help:     "set and get options on a channel",
+		Safe:     true,
+		Arity:    1,
+		Variadic: true,
+		Run:      wrapChannelFunc(chanConfigure),
+	}
+}
+
+func RunFlush() Executer {
+	return Builtin{
+		Name:  "flush",
+		Help:  "flush buffered output for a channel",
+		Safe:  true,
+		Arity: 1,
+		Run:   wrapChannelFunc(chanFlush),
+	}
+}
+
+func RunFBlocked() Executer {
+	return Builtin{
+		Name:  "fblocked",
+		Help:  "test whether the last input operation exhausted all available input",
+		Safe:  true,
+		Arity: 1,
+		Run:   wrapChannelFunc(chanBlocked),
+	}
+}
+
 func RunOpen() Executer {
 	return Builtin{
 		Name:     "open",
@@ -184,10 +249,11 @@ func RunOpen() Executer {
 
 func RunClose() Executer {
 	return Builtin{
-		Name:  "close",
-		Safe:  true,
-		Arity: 1,
-		Run:   wrapChannelFunc(chanClose),
+		Name:     "close",
+		Safe:     true,
+		Arity:    1,
+		Variadic: true,
+		Run:      wrapChannelFunc(chanClose),
 	}
 }
 
@@ -275,7 +341,16 @@ func chanPuts(ch ChannelHandler, args []env.Value) (env.Value, error) {
 	if err != nil {
 		return nil, err
 	}
-	ch.Print(file.String(), slices.Fst(args).String())
+	switch len(args) {
+	case 1:
+	case 2:
+		file, args = slices.Fst(args), slices.Rest(args)
+	default:
+		return nil, ErrArgument
+	}
+	if err := ch.Print(file.String(), slices.Fst(args).String()); err != nil {
+		return nil, err
+	}
 	if !env.ToBool(nonl) {
 		ch.Println(file.String(), "")
 	}
@@ -287,12 +362,83 @@ func chanOpen(ch ChannelHandler, args []env.Value) (env.Value, error) {
 	if v := slices.Snd(args); v != nil {
 		mode = v.String()
 	}
-	file, err := ch.Open(slices.Fst(args).String(), mode)
+	name := slices.Fst(args).String()
+	if strings.HasPrefix(name, "|") {
+		return openPipeline(ch, name[1:], mode)
+	}
+	file, err := ch.Open(name, mode)
 	return env.Str(file), err
 }
 
+func openPipeline(ch ChannelHandler, cmd, mode string) (env.Value, error) {
+	x, ok := ch.(interface {
+		Attach(io.ReadWriteCloser) string
+	})
+	if !ok {
+		return nil, fmt.Errorf("interpreter can not attach command pipeline")
+	}
+	if r, ok := ch.(interface{ Root() bool }); ch.IsSafe() && (!ok || !r.Root()) {
+		return nil, fmt.Errorf("command pipeline can not be opened in safe interpreter")
+	}
+	args, err := listValues(env.Str(cmd))
+	if err != nil {
+		return nil, err
+	}
+	p, err := parsePipeline(ch, args)
+	if err != nil {
+		return nil, err
+	}
+	if p.background {
+		p.close()
+		return nil, fmt.Errorf("can't run command pipeline in background when opened as channel")
+	}
+	c, err := p.open(mode)
+	if err != nil {
+		p.close()
+		return nil, err
+	}
+	return env.Str(x.Attach(c)), nil
+}
+
 func chanClose(ch ChannelHandler, args []env.Value) (env.Value, error) {
-	return nil, ch.Close(slices.Fst(args).String())
+	if len(args) > 2 {
+		return nil, fmt.Errorf("close: %w", ErrArgument)
+	}
+	var (
+		fd  = slices.Fst(args).String()
+		err error
+	)
+	if dir := slices.Snd(args); dir != nil {
+		err = halfClose(ch, fd, dir.String())
+	} else {
+		err = ch.Close(fd)
+	}
+	if err != nil {
+		var e Error
+		if errors.As(err, &e) {
+			return nil, e
+		}
+		return nil, childError(err, "")
+	}
+	return env.EmptyStr(), nil
+}
+
+func halfClose(ch ChannelHandler, fd, dir string) error {
+	x, ok := ch.(interface {
+		CloseRead(string) error
+		CloseWrite(string) error
+	})
+	if !ok {
+		return fmt.Errorf("interpreter can not half close channels")
+	}
+	switch dir {
+	case "r", "read":
+		return x.CloseRead(fd)
+	case "w", "write":
+		return x.CloseWrite(fd)
+	default:
+		return fmt.Errorf("%s: bad direction, must be read or write", dir)
+	}
 }
 
 func chanEof(ch ChannelHandler, args []env.Value) (env.Value, error) {
@@ -331,29 +477,93 @@ func chanTell(ch ChannelHandler, args []env.Value) (env.Value, error) {
 
 func chanGets(ch ChannelHandler, args []env.Value) (env.Value, error) {
 	str, err := ch.Gets(slices.Fst(args).String())
-	if err != nil {
+	if err != nil && !errors.Is(err, io.EOF) {
 		return nil, err
 	}
 	res := env.Str(str)
 	if v := slices.Snd(args); v != nil {
 		ch.Define(v.String(), res)
+		if err != nil {
+			return env.Int(-1), nil
+		}
+		return env.Int(int64(utf8.RuneCountInString(str))), nil
 	}
 	return res, nil
 }
 
+func chanFlush(ch ChannelHandler, args []env.Value) (env.Value, error) {
+	return env.EmptyStr(), ch.Flush(slices.Fst(args).String())
+}
+
+func chanBlocked(ch ChannelHandler, args []env.Value) (env.Value, error) {
+	ok, err := ch.Blocked(slices.Fst(args).String())
+	return env.Bool(ok), err
+}
+
+func chanConfigure(ch ChannelHandler, args []env.Value) (env.Value, error) {
+	var (
+		fd   = slices.Fst(args).String()
+		rest = slices.Rest(args)
+	)
+	switch n := len(rest); {
+	case n == 0:
+		names, err := ch.ConfigNames(fd)
+		if err != nil {
+			return nil, err
+		}
+		var list []env.Value
+		for _, n := range names {
+			vs, err := ch.Config(fd, n)
+			if err != nil {
+				continue
+			}
+			list = append(list, env.Str(n), configValue(vs))
+		}
+		return env.ListFrom(list...), nil
+	case n == 1:
+		vs, err := ch.Config(fd, slices.Fst(rest).String())
+		if err != nil {
+			return nil, err
+		}
+		return configValue(vs), nil
+	case n%2 != 0:
+		return nil, fmt.Errorf("chan configure: %w: option without value", ErrArgument)
+	default:
+	}
+	for j := 0; j < len(rest); j += 2 {
+		vs, err := env.ToStringList(rest[j+1])
+		if err != nil {
+			return nil, err
+		}
+		if err := ch.Configure(fd, rest[j].String(), vs); err != nil {
+			return nil, err
+		}
+	}
+	return env.EmptyStr(), nil
+}
+
+func configValue(vs []string) env.Value {
+	if len(vs) == 1 {
+		return env.Str(vs[0])
+	}
+	return env.ListFromStrings(vs)
+}
+
 func chanRead(ch ChannelHandler, args []env.Value) (env.Value, error) {
 	var (
 		size    int
 		err     error
 		nonl, _ = ch.Resolve("nonewline")
 	)
-	size, err = env.ToInt(slices.Lst(args))
-	if err != nil {
-		return nil, err
+	if v := slices.Snd(args); v != nil {
+		size, err = env.ToInt(v)
+		if err != nil {
+			return nil, err
+		}
 	}
 	str, err := ch.Read(slices.Fst(args).String(), size)
-	if err == nil && !env.ToBool(nonl) {
-		str = strings.TrimSpace(str)
+	if err == nil && env.ToBool(nonl) {
+		str = strings.TrimSuffix(str, "\n")
 	}
 	return env.Str(str), err
 }
@@ -128,7 +128,7 @@ func Type(file string) (string, error) {
 	var typ string
 	switch mod := fi.Mode().Type(); {
 	default:
-		return "", fmt.Errorf("%s: unknown file type")
+		return "", fmt.Errorf("%s: unknown file type", file)
 	case mod.IsRegular():
 		typ = "file"
 	case mod.IsDir():
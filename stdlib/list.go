@@ -6,6 +6,7 @@ import (
 	"strings"
 
 	"github.com/midbel/gotcl/env"
+	"github.com/midbel/gotcl/glob"
 	"github.com/midbel/slices"
 )
 
@@ -57,9 +58,58 @@ func RunLSort() Executer {
 func RunLSearch() Executer {
 	return Builtin{
 		Name:  "lsearch",
-		Arity: 1,
+		Arity: 2,
 		Safe:  true,
-		Run:   listSearch,
+		Options: []Option{
+			{
+				Name:  "exact",
+				Flag:  true,
+				Value: env.False(),
+				Check: CheckBool,
+			},
+			{
+				Name:  "glob",
+				Flag:  true,
+				Value: env.False(),
+				Check: CheckBool,
+			},
+			{
+				Name:  "regexp",
+				Flag:  true,
+				Value: env.False(),
+				Check: CheckBool,
+			},
+			{
+				Name:  "nocase",
+				Flag:  true,
+				Value: env.False(),
+				Check: CheckBool,
+			},
+			{
+				Name:  "all",
+				Flag:  true,
+				Value: env.False(),
+				Check: CheckBool,
+			},
+			{
+				Name:  "inline",
+				Flag:  true,
+				Value: env.False(),
+				Check: CheckBool,
+			},
+			{
+				Name:  "not",
+				Flag:  true,
+				Value: env.False(),
+				Check: CheckBool,
+			},
+			{
+				Name:  "start",
+				Value: env.Zero(),
+				Check: CheckNumber,
+			},
+		},
+		Run: listSearch,
 	}
 }
 
@@ -409,11 +459,11 @@ func listAppend(i Interpreter, args []env.Value) (env.Value, error) {
 	if err != nil {
 		list = env.EmptyList()
 	}
-	for _, a := range slices.Rest(args) {
-		list.(env.List).Append(a)
+	if list, err = list.ToList(); err != nil {
+		return nil, err
 	}
-	i.Define(slices.Fst(args).String(), list)
-	return list, nil
+	vs := append(list.(env.List).Values(), slices.Rest(args)...)
+	return setVar(i, slices.Fst(args).String(), env.ListFrom(vs...))
 }
 
 func listMap(i Interpreter, args []env.Value) (env.Value, error) {
@@ -427,7 +477,7 @@ func listMap(i Interpreter, args []env.Value) (env.Value, error) {
 	var res []env.Value
 	for _, a := range list.(env.List).Values() {
 		i.Define(slices.Fst(args).String(), a)
-		r, err := i.Execute(strings.NewReader(slices.Lst(args).String()))
+		r, err := executeValue(i, slices.Lst(args))
 		if err != nil && !errors.Is(err, ErrContinue) {
 			if errors.Is(err, ErrBreak) {
 				break
@@ -536,7 +586,74 @@ func listSet(i Interpreter, args []env.Value) (env.Value, error) {
 }
 
 func listSearch(i Interpreter, args []env.Value) (env.Value, error) {
-	return nil, nil
+	var (
+		exact, _  = i.Resolve("exact")
+		regex, _  = i.Resolve("regexp")
+		nocase, _ = i.Resolve("nocase")
+		all, _    = i.Resolve("all")
+		inline, _ = i.Resolve("inline")
+		not, _    = i.Resolve("not")
+		start, _  = i.Resolve("start")
+		pat       = slices.Snd(args).String()
+		match     func(string) bool
+	)
+	switch {
+	case env.ToBool(exact):
+		match = func(str string) bool {
+			if env.ToBool(nocase) {
+				return strings.EqualFold(str, pat)
+			}
+			return str == pat
+		}
+	case env.ToBool(regex):
+		re, err := compileRegexp(pat, env.ToBool(nocase), false)
+		if err != nil {
+			return nil, err
+		}
+		match = re.MatchString
+	default:
+		if env.ToBool(nocase) {
+			pat = strings.ToLower(pat)
+		}
+		match = func(str string) bool {
+			if env.ToBool(nocase) {
+				str = strings.ToLower(str)
+			}
+			return glob.Match(str, pat)
+		}
+	}
+	list, err := listValues(slices.Fst(args))
+	if err != nil {
+		return nil, err
+	}
+	offset, err := env.ToInt(start)
+	if err != nil {
+		return nil, err
+	}
+	if offset < 0 {
+		offset = 0
+	}
+	var res []env.Value
+	for j := offset; j < len(list); j++ {
+		if ok := match(list[j].String()); ok == env.ToBool(not) {
+			continue
+		}
+		var v env.Value = env.Int(int64(j))
+		if env.ToBool(inline) {
+			v = list[j]
+		}
+		if !env.ToBool(all) {
+			return v, nil
+		}
+		res = append(res, v)
+	}
+	if env.ToBool(all) {
+		return env.ListFrom(res...), nil
+	}
+	if env.ToBool(inline) {
+		return env.EmptyStr(), nil
+	}
+	return env.Int(-1), nil
 }
 
 func listSort(i Interpreter, args []env.Value) (env.Value, error) {
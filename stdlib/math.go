@@ -3,8 +3,13 @@ package stdlib
 import (
 	"fmt"
 	"math"
+	"math/big"
+	"math/rand"
+	"sync"
+	"time"
 
 	"github.com/midbel/gotcl/env"
+	"github.com/midbel/gotcl/expr/types"
 	"github.com/midbel/slices"
 )
 
@@ -322,7 +327,7 @@ func RunRound() Executer {
 func RunFmod() Executer {
 	return Builtin{
 		Name:  "fmod",
-		Arity: 1,
+		Arity: 2,
 		Safe:  true,
 		Run:   runFmod,
 	}
@@ -454,142 +459,101 @@ func RunRadian() Executer {
 }
 
 func runIncr(i Interpreter, args []env.Value) (env.Value, error) {
-	var step int
-	if v := slices.Snd(args); v != nil {
-		x, err := env.ToInt(v)
-		if err != nil {
-			return nil, err
-		}
-		step = x
-	} else {
-		step++
-	}
-	v, err := i.Resolve(slices.Fst(args).String())
-	if err != nil {
-		return nil, err
-	}
-	n, err := env.ToInt(v)
-	if err != nil {
-		return nil, err
-	}
-	res := env.Int(int64(n - step))
-	i.Define(slices.Fst(args).String(), res)
-	return res, nil
+	return incrVar(i, args, (*big.Int).Add)
 }
 
 func runDecr(i Interpreter, args []env.Value) (env.Value, error) {
-	var step int
+	return incrVar(i, args, (*big.Int).Sub)
+}
+
+func incrVar(i Interpreter, args []env.Value, do func(*big.Int, *big.Int, *big.Int) *big.Int) (env.Value, error) {
+	step := big.NewInt(1)
 	if v := slices.Snd(args); v != nil {
-		x, err := env.ToInt(v)
+		x, err := toBig(v)
 		if err != nil {
 			return nil, err
 		}
 		step = x
-	} else {
-		step++
 	}
 	v, err := i.Resolve(slices.Fst(args).String())
 	if err != nil {
 		return nil, err
 	}
-	n, err := env.ToInt(v)
+	n, err := toBig(v)
 	if err != nil {
 		return nil, err
 	}
-	res := env.Int(int64(n - step))
-	i.Define(slices.Fst(args).String(), res)
-	return res, nil
+	return setVar(i, slices.Fst(args).String(), env.BigInt(do(n, n, step)))
 }
 
 func runAdd(i Interpreter, args []env.Value) (env.Value, error) {
-	return withNumbers(args, func(fst, lst float64) (float64, error) {
-		return fst + lst, nil
-	})
+	if len(args) == 0 {
+		return env.Int(0), nil
+	}
+	return withNumbers(args, types.Value.Add)
 }
 
 func runSub(i Interpreter, args []env.Value) (env.Value, error) {
 	if len(args) == 1 {
-		f, err := env.ToFloat(slices.Fst(args))
+		v, err := types.AsValue(slices.Fst(args))
 		if err != nil {
 			return nil, err
 		}
-		return env.Float(-f), nil
+		if v, err = v.Rev(); err != nil {
+			return nil, err
+		}
+		return types.AsEnv(v), nil
 	}
-	return withNumbers(args, func(fst, lst float64) (float64, error) {
-		return fst - lst, nil
-	})
+	return withNumbers(args, types.Value.Sub)
 }
 
 func runMul(i Interpreter, args []env.Value) (env.Value, error) {
 	if len(args) == 0 {
 		return env.Int(1), nil
 	}
-	return withNumbers(args, func(fst, lst float64) (float64, error) {
-		return fst * lst, nil
-	})
+	return withNumbers(args, types.Value.Mul)
 }
 
 func runDiv(i Interpreter, args []env.Value) (env.Value, error) {
 	if len(args) == 1 {
 		args = slices.Prepend(env.Float(1), args)
 	}
-	return withNumbers(args, func(fst, lst float64) (float64, error) {
-		if lst == 0 {
-			return 0, fmt.Errorf("division by zero")
-		}
-		return fst / lst, nil
-	})
+	return withNumbers(args, types.Value.Div)
 }
 
 func runMod(i Interpreter, args []env.Value) (env.Value, error) {
-	return withNumbers(args, func(fst, lst float64) (float64, error) {
-		if lst == 0 {
-			return 0, fmt.Errorf("division by zero")
-		}
-		return math.Mod(fst, lst), nil
-	})
+	return withNumbers(args, types.Value.Mod)
 }
 
 func runPow(i Interpreter, args []env.Value) (env.Value, error) {
-	return withNumbers(args, func(fst, lst float64) (float64, error) {
-		return math.Pow(fst, lst), nil
-	})
+	if len(args) == 0 {
+		return env.Int(1), nil
+	}
+	return withNumbers(args, types.Value.Pow)
 }
 
 func runEq(i Interpreter, args []env.Value) (env.Value, error) {
-	return withCompare(args, func(fst, lst float64) (bool, error) {
-		return fst == lst, nil
-	})
+	return withCompare(args, types.Value.Eq)
 }
 
 func runNe(i Interpreter, args []env.Value) (env.Value, error) {
-	return withCompare(args, func(fst, lst float64) (bool, error) {
-		return fst != lst, nil
-	})
+	return withCompare(args, types.Value.Ne)
 }
 
 func runLt(i Interpreter, args []env.Value) (env.Value, error) {
-	return withCompare(args, func(fst, lst float64) (bool, error) {
-		return fst < lst, nil
-	})
+	return withCompare(args, types.Value.Lt)
 }
 
 func runLe(i Interpreter, args []env.Value) (env.Value, error) {
-	return withCompare(args, func(fst, lst float64) (bool, error) {
-		return fst <= lst, nil
-	})
+	return withCompare(args, types.Value.Le)
 }
 
 func runGt(i Interpreter, args []env.Value) (env.Value, error) {
-	return withCompare(args, func(fst, lst float64) (bool, error) {
-		return fst > lst, nil
-	})
+	return withCompare(args, types.Value.Gt)
 }
 
 func runGe(i Interpreter, args []env.Value) (env.Value, error) {
-	return withCompare(args, func(fst, lst float64) (bool, error) {
-		return fst > lst, nil
-	})
+	return withCompare(args, types.Value.Ge)
 }
 
 func runNot(i Interpreter, args []env.Value) (env.Value, error) {
@@ -603,42 +567,44 @@ func runNot(i Interpreter, args []env.Value) (env.Value, error) {
 	return env.True(), nil
 }
 
-func withCompare(args []env.Value, do func(float64, float64) (bool, error)) (env.Value, error) {
-	r, err := env.ToFloat(slices.Fst(args))
+func withCompare(args []env.Value, do func(types.Value, types.Value) (types.Value, error)) (env.Value, error) {
+	r, err := types.AsValue(slices.Fst(args))
 	if err != nil {
 		return nil, err
 	}
-	var ok bool
 	for _, v := range slices.Rest(args) {
-		c, err := env.ToFloat(v)
+		c, err := types.AsValue(v)
+		if err != nil {
+			return nil, err
+		}
+		ok, err := do(types.Coerce(r, c))
 		if err != nil {
 			return nil, err
 		}
-		ok, err = do(r, c)
-		if !ok || err != nil {
-			return env.False(), err
+		if b, _ := types.AsBool(ok); !b {
+			return env.False(), nil
 		}
 		r = c
 	}
-	return env.Bool(ok), nil
+	return env.True(), nil
 }
 
-func withNumbers(args []env.Value, do func(float64, float64) (float64, error)) (env.Value, error) {
-	res, err := env.ToFloat(slices.Fst(args))
+func withNumbers(args []env.Value, do func(types.Value, types.Value) (types.Value, error)) (env.Value, error) {
+	res, err := types.AsValue(slices.Fst(args))
 	if err != nil {
 		return nil, err
 	}
 	for _, v := range slices.Rest(args) {
-		c, err := env.ToFloat(v)
+		c, err := types.AsValue(v)
 		if err != nil {
 			return nil, err
 		}
-		res, err = do(res, c)
+		res, err = do(types.Coerce(res, c))
 		if err != nil {
 			return nil, err
 		}
 	}
-	return env.Float(res), nil
+	return types.AsEnv(res), nil
 }
 
 func runDegree(i Interpreter, args []env.Value) (env.Value, error) {
@@ -654,6 +620,9 @@ func runRadian(i Interpreter, args []env.Value) (env.Value, error) {
 }
 
 func runAbs(i Interpreter, args []env.Value) (env.Value, error) {
+	if n, err := toBig(slices.Fst(args)); err == nil {
+		return env.BigInt(n.Abs(n)), nil
+	}
 	return withFloat(slices.Fst(args), math.Abs)
 }
 
@@ -694,7 +663,7 @@ func runTan(i Interpreter, args []env.Value) (env.Value, error) {
 }
 
 func runTanh(i Interpreter, args []env.Value) (env.Value, error) {
-	return withFloat(slices.Fst(args), math.Tan)
+	return withFloat(slices.Fst(args), math.Tanh)
 }
 
 func runHypot(i Interpreter, args []env.Value) (env.Value, error) {
@@ -710,11 +679,15 @@ func runBool(i Interpreter, args []env.Value) (env.Value, error) {
 }
 
 func runDouble(i Interpreter, args []env.Value) (env.Value, error) {
-	return slices.Fst(args).ToNumber()
+	return withFloat(slices.Fst(args), func(f float64) float64 {
+		return f
+	})
 }
 
 func runEntier(i Interpreter, args []env.Value) (env.Value, error) {
-	return slices.Fst(args).ToNumber()
+	return withInteger(slices.Fst(args), func(n *big.Int) (*big.Int, error) {
+		return n, nil
+	})
 }
 
 func runCeil(i Interpreter, args []env.Value) (env.Value, error) {
@@ -726,7 +699,18 @@ func runFloor(i Interpreter, args []env.Value) (env.Value, error) {
 }
 
 func runRound(i Interpreter, args []env.Value) (env.Value, error) {
-	return withFloat(slices.Fst(args), math.Round)
+	if n, err := toBig(slices.Fst(args)); err == nil {
+		return env.BigInt(n), nil
+	}
+	f, err := env.ToFloat(slices.Fst(args))
+	if err != nil {
+		return nil, err
+	}
+	if math.IsInf(f, 0) || math.IsNaN(f) {
+		return nil, fmt.Errorf("integer value too large to represent")
+	}
+	n, _ := big.NewFloat(math.Round(f)).Int(nil)
+	return env.BigInt(n), nil
 }
 
 func runFmod(i Interpreter, args []env.Value) (env.Value, error) {
@@ -734,7 +718,7 @@ func runFmod(i Interpreter, args []env.Value) (env.Value, error) {
 }
 
 func runInt(i Interpreter, args []env.Value) (env.Value, error) {
-	return slices.Fst(args).ToNumber()
+	return runWide(i, args)
 }
 
 func runExp(i Interpreter, args []env.Value) (env.Value, error) {
@@ -761,16 +745,46 @@ func runRaise(i Interpreter, args []env.Value) (env.Value, error) {
 	return withFloat2(slices.Fst(args), slices.Snd(args), math.Pow)
 }
 
+var random = struct {
+	sync.Mutex
+	*rand.Rand
+}{
+	Rand: rand.New(rand.NewSource(time.Now().UnixNano())),
+}
+
 func runRand(i Interpreter, args []env.Value) (env.Value, error) {
-	return nil, nil
+	random.Lock()
+	defer random.Unlock()
+	return env.Float(nextRandom()), nil
 }
 
 func runSrand(i Interpreter, args []env.Value) (env.Value, error) {
-	return nil, nil
+	seed, err := toInteger(slices.Fst(args))
+	if err != nil {
+		return nil, err
+	}
+	random.Lock()
+	defer random.Unlock()
+	random.Seed(seed)
+	return env.Float(nextRandom()), nil
+}
+
+// nextRandom gives a number in the open range (0, 1) like rand() in Tcl.
+func nextRandom() float64 {
+	for {
+		if f := random.Float64(); f > 0 {
+			return f
+		}
+	}
 }
 
 func runIsqrt(i Interpreter, args []env.Value) (env.Value, error) {
-	return nil, nil
+	return withInteger(slices.Fst(args), func(n *big.Int) (*big.Int, error) {
+		if n.Sign() < 0 {
+			return nil, fmt.Errorf("square root of negative argument")
+		}
+		return new(big.Int).Sqrt(n), nil
+	})
 }
 
 func runSqrt(i Interpreter, args []env.Value) (env.Value, error) {
@@ -778,7 +792,43 @@ func runSqrt(i Interpreter, args []env.Value) (env.Value, error) {
 }
 
 func runWide(i Interpreter, args []env.Value) (env.Value, error) {
-	return nil, nil
+	return withInteger(slices.Fst(args), func(n *big.Int) (*big.Int, error) {
+		return big.NewInt(wideInteger(n)), nil
+	})
+}
+
+// wideInteger keeps the lower 64 bits of n.
+func wideInteger(n *big.Int) int64 {
+	if n.IsInt64() {
+		return n.Int64()
+	}
+	mask := new(big.Int).SetUint64(math.MaxUint64)
+	return int64(new(big.Int).And(n, mask).Uint64())
+}
+
+func toBig(v env.Value) (*big.Int, error) {
+	n, err := v.ToNumber()
+	if err == nil {
+		if x, ok := n.(env.Number); ok && x.IsInt() {
+			return x.Big(), nil
+		}
+	}
+	return nil, fmt.Errorf("expected integer but got %q", v.String())
+}
+
+func withInteger(v env.Value, do func(*big.Int) (*big.Int, error)) (env.Value, error) {
+	x, err := types.AsValue(v)
+	if err != nil {
+		return nil, err
+	}
+	n, err := types.AsBig(x)
+	if err != nil {
+		return nil, err
+	}
+	if n, err = do(n); err != nil {
+		return nil, err
+	}
+	return env.BigInt(n), nil
 }
 
 func withFloat(v env.Value, do func(float64) float64) (env.Value, error) {
@@ -801,17 +851,22 @@ func withFloat2(fst, snd env.Value, do func(float64, float64) float64) (env.Valu
 }
 
 func cmpFloat(args []env.Value, cmp func(float64, float64) float64) (env.Value, error) {
-	var (
-		val float64
-		tmp float64
-		err error
-	)
-	for i := 0; i < len(args); i++ {
-		tmp, err = env.ToFloat(args[i])
+	if len(args) == 0 {
+		return nil, fmt.Errorf("too few arguments to math function")
+	}
+	res := slices.Fst(args)
+	val, err := env.ToFloat(res)
+	if err != nil {
+		return nil, err
+	}
+	for _, a := range slices.Rest(args) {
+		tmp, err := env.ToFloat(a)
 		if err != nil {
-			break
+			return nil, err
+		}
+		if cmp(val, tmp) != val {
+			val, res = tmp, a
 		}
-		val = cmp(val, tmp)
 	}
-	return env.Float(val), nil
+	return res.ToNumber()
 }
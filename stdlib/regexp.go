@@ -0,0 +1,334 @@
+package stdlib
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/midbel/gotcl/env"
+	"github.com/midbel/slices"
+)
+
+func RunRegexp() Executer {
+	return Builtin{
+		Name:     "regexp",
+		Help:     "match a regular expression against a string",
+		Arity:    2,
+		Variadic: true,
+		Safe:     true,
+		Options: []Option{
+			{
+				Name:  "nocase",
+				Flag:  true,
+				Value: env.False(),
+				Check: CheckBool,
+			},
+			{
+				Name:  "all",
+				Flag:  true,
+				Value: env.False(),
+				Check: CheckBool,
+			},
+			{
+				Name:  "inline",
+				Flag:  true,
+				Value: env.False(),
+				Check: CheckBool,
+			},
+			{
+				Name:  "indices",
+				Flag:  true,
+				Value: env.False(),
+				Check: CheckBool,
+			},
+			{
+				Name:  "line",
+				Flag:  true,
+				Value: env.False(),
+				Check: CheckBool,
+			},
+			{
+				Name:  "start",
+				Value: env.Zero(),
+				Check: CheckNumber,
+			},
+		},
+		Run: runRegexp,
+	}
+}
+
+func RunRegsub() Executer {
+	return Builtin{
+		Name:     "regsub",
+		Help:     "perform substitutions based on regular expression pattern matching",
+		Arity:    3,
+		Variadic: true,
+		Safe:     true,
+		Options: []Option{
+			{
+				Name:  "nocase",
+				Flag:  true,
+				Value: env.False(),
+				Check: CheckBool,
+			},
+			{
+				Name:  "all",
+				Flag:  true,
+				Value: env.False(),
+				Check: CheckBool,
+			},
+			{
+				Name:  "line",
+				Flag:  true,
+				Value: env.False(),
+				Check: CheckBool,
+			},
+			{
+				Name:  "command",
+				Flag:  true,
+				Value: env.False(),
+				Check: CheckBool,
+			},
+			{
+				Name:  "start",
+				Value: env.Zero(),
+				Check: CheckNumber,
+			},
+		},
+		Run: runRegsub,
+	}
+}
+
+func runRegexp(i Interpreter, args []env.Value) (env.Value, error) {
+	var (
+		nocase, _  = i.Resolve("nocase")
+		line, _    = i.Resolve("line")
+		all, _     = i.Resolve("all")
+		inline, _  = i.Resolve("inline")
+		indices, _ = i.Resolve("indices")
+		vars       = slices.Take(args, 2)
+	)
+	if env.ToBool(inline) && len(vars) > 0 {
+		return nil, fmt.Errorf("regexp match variables not allowed when using -inline")
+	}
+	re, err := compileRegexp(slices.Fst(args).String(), env.ToBool(nocase), env.ToBool(line))
+	if err != nil {
+		return nil, err
+	}
+	str := slices.Snd(args).String()
+	off, err := getStartOffset(i, str)
+	if err != nil {
+		return nil, err
+	}
+	limit := 1
+	if env.ToBool(all) {
+		limit = -1
+	}
+	var (
+		matches = re.FindAllStringSubmatchIndex(str[off:], limit)
+		groups  = re.NumSubexp() + 1
+		getter  = func(m []int, n int) env.Value {
+			return getGroup(str, off, m, n)
+		}
+	)
+	if env.ToBool(indices) {
+		getter = func(m []int, n int) env.Value {
+			return getGroupIndices(str, off, m, n)
+		}
+	}
+	if env.ToBool(inline) {
+		var list []env.Value
+		for _, m := range matches {
+			for j := 0; j < groups; j++ {
+				list = append(list, getter(m, j))
+			}
+		}
+		return env.ListFrom(list...), nil
+	}
+	if len(matches) > 0 {
+		m := slices.Lst(matches)
+		for j, v := range vars {
+			i.Define(v.String(), getter(m, j))
+		}
+	}
+	return env.Int(int64(len(matches))), nil
+}
+
+func runRegsub(i Interpreter, args []env.Value) (env.Value, error) {
+	var (
+		nocase, _ = i.Resolve("nocase")
+		line, _   = i.Resolve("line")
+		all, _    = i.Resolve("all")
+		cmd, _    = i.Resolve("command")
+	)
+	re, err := compileRegexp(slices.Fst(args).String(), env.ToBool(nocase), env.ToBool(line))
+	if err != nil {
+		return nil, err
+	}
+	str := slices.Snd(args).String()
+	off, err := getStartOffset(i, str)
+	if err != nil {
+		return nil, err
+	}
+	limit := 1
+	if env.ToBool(all) {
+		limit = -1
+	}
+	var (
+		spec    = slices.At(args, 2)
+		replace = func(m []int) (string, error) {
+			return expandSubspec(spec.String(), str[off:], m), nil
+		}
+	)
+	if env.ToBool(cmd) {
+		replace = func(m []int) (string, error) {
+			return execSubspec(i, spec, str[off:], m)
+		}
+	}
+	var (
+		matches = re.FindAllStringSubmatchIndex(str[off:], limit)
+		rest    = str[off:]
+		prev    int
+		buf     strings.Builder
+	)
+	buf.WriteString(str[:off])
+	for _, m := range matches {
+		buf.WriteString(rest[prev:m[0]])
+		rep, err := replace(m)
+		if err != nil {
+			return nil, err
+		}
+		buf.WriteString(rep)
+		prev = m[1]
+	}
+	buf.WriteString(rest[prev:])
+
+	res := env.Str(buf.String())
+	if v := slices.At(args, 3); v != nil {
+		i.Define(v.String(), res)
+		return env.Int(int64(len(matches))), nil
+	}
+	return res, nil
+}
+
+func compileRegexp(pat string, nocase, line bool) (*regexp.Regexp, error) {
+	flags := "s"
+	if line {
+		flags = "m"
+	}
+	if nocase {
+		flags += "i"
+	}
+	re, err := regexp.Compile(fmt.Sprintf("(?%s)%s", flags, pat))
+	if err != nil {
+		return nil, fmt.Errorf("couldn't compile regular expression pattern: %w", err)
+	}
+	return re, nil
+}
+
+func getStartOffset(i Interpreter, str string) (int, error) {
+	start, err := i.Resolve("start")
+	if err != nil {
+		return 0, nil
+	}
+	n, err := env.ToInt(start)
+	if err != nil {
+		return 0, err
+	}
+	if n <= 0 {
+		return 0, nil
+	}
+	var off int
+	for ; n > 0 && off < len(str); n-- {
+		_, z := utf8.DecodeRuneInString(str[off:])
+		off += z
+	}
+	return off, nil
+}
+
+func getGroup(str string, off int, m []int, n int) env.Value {
+	if 2*n+1 >= len(m) || m[2*n] < 0 {
+		return env.EmptyStr()
+	}
+	return env.Str(str[off+m[2*n] : off+m[2*n+1]])
+}
+
+func getGroupIndices(str string, off int, m []int, n int) env.Value {
+	if 2*n+1 >= len(m) || m[2*n] < 0 {
+		return env.ListFrom(env.Int(-1), env.Int(-1))
+	}
+	var (
+		fst = utf8.RuneCountInString(str[:off+m[2*n]])
+		lst = fst + utf8.RuneCountInString(str[off+m[2*n]:off+m[2*n+1]]) - 1
+	)
+	return env.ListFrom(env.Int(int64(fst)), env.Int(int64(lst)))
+}
+
+func expandSubspec(spec, str string, m []int) string {
+	group := func(n int) string {
+		if 2*n+1 >= len(m) || m[2*n] < 0 {
+			return ""
+		}
+		return str[m[2*n]:m[2*n+1]]
+	}
+	var buf strings.Builder
+	for j := 0; j < len(spec); j++ {
+		c := spec[j]
+		switch {
+		case c == '&':
+			buf.WriteString(group(0))
+		case c == '\\' && j+1 < len(spec):
+			next := spec[j+1]
+			switch {
+			case next >= '0' && next <= '9':
+				buf.WriteString(group(int(next - '0')))
+				j++
+			case next == '&' || next == '\\':
+				buf.WriteByte(next)
+				j++
+			default:
+				buf.WriteByte(c)
+			}
+		default:
+			buf.WriteByte(c)
+		}
+	}
+	return buf.String()
+}
+
+func execSubspec(i Interpreter, spec env.Value, str string, m []int) (string, error) {
+	x, ok := i.(interface {
+		LookupExec(string) (Executer, error)
+	})
+	if !ok {
+		return "", fmt.Errorf("interpreter can not lookup for command")
+	}
+	prefix, err := listValues(spec)
+	if err != nil {
+		return "", err
+	}
+	if len(prefix) == 0 {
+		return "", fmt.Errorf("regsub: command prefix must be a list of at least one element")
+	}
+	exec, err := x.LookupExec(slices.Fst(prefix).String())
+	if err != nil {
+		return "", err
+	}
+	args := slices.Rest(prefix)
+	for j := 0; 2*j+1 < len(m); j++ {
+		var v string
+		if m[2*j] >= 0 {
+			v = str[m[2*j]:m[2*j+1]]
+		}
+		args = append(args, env.Str(v))
+	}
+	res, err := exec.Execute(i, args)
+	if err != nil {
+		return "", err
+	}
+	if res == nil {
+		return "", nil
+	}
+	return res.String(), nil
+}
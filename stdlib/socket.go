@@ -0,0 +1,90 @@
+package stdlib
+
+import (
+	"fmt"
+
+	"github.com/midbel/gotcl/env"
+	"github.com/midbel/slices"
+)
+
+type SocketHandler interface {
+	Interpreter
+
+	Dial(string, string, string, string, bool) (string, error)
+	Listen(string, string, string) (string, error)
+}
+
+func RunSocket() Executer {
+	return Builtin{
+		Name:     "socket",
+		Help:     "open a TCP network connection",
+		Arity:    1,
+		Variadic: true,
+		Safe:     false,
+		Options: []Option{
+			{
+				Name:  "server",
+				Value: env.EmptyStr(),
+				Check: CheckString,
+			},
+			{
+				Name:  "myaddr",
+				Value: env.EmptyStr(),
+				Check: CheckString,
+			},
+			{
+				Name:  "myport",
+				Value: env.EmptyStr(),
+				Check: CheckString,
+			},
+			{
+				Name:  "async",
+				Flag:  true,
+				Value: env.False(),
+				Check: CheckBool,
+			},
+		},
+		Run: runSocket,
+	}
+}
+
+func runSocket(i Interpreter, args []env.Value) (env.Value, error) {
+	sh, ok := i.(SocketHandler)
+	if !ok {
+		return nil, fmt.Errorf("interpreter can not handle sockets")
+	}
+	var (
+		server, _ = i.Resolve("server")
+		myaddr, _ = i.Resolve("myaddr")
+		myport, _ = i.Resolve("myport")
+		async, _  = i.Resolve("async")
+	)
+	if cmd := server.String(); cmd != "" {
+		if env.ToBool(async) {
+			return nil, fmt.Errorf("cannot set -async option for server sockets")
+		}
+		if myport.String() != "" {
+			return nil, fmt.Errorf("option -myport is not valid for servers")
+		}
+		if len(args) != 1 {
+			return nil, fmt.Errorf("socket -server: %w", ErrArgument)
+		}
+		fd, err := sh.Listen(myaddr.String(), slices.Fst(args).String(), cmd)
+		if err != nil {
+			return nil, err
+		}
+		return env.Str(fd), nil
+	}
+	if len(args) != 2 {
+		return nil, fmt.Errorf("socket: %w", ErrArgument)
+	}
+	var (
+		host = slices.Fst(args).String()
+		port = slices.Snd(args).String()
+	)
+	fd, err := sh.Dial(host, port, myaddr.String(), myport.String(), env.ToBool(async))
+	if err != nil {
+		return nil, err
+	}
+	return env.Str(fd), nil
+}
@@ -8,6 +8,7 @@ import (
 	"strings"
 
 	"github.com/midbel/gotcl/env"
+	"github.com/midbel/gotcl/expr/types"
 	"github.com/midbel/slices"
 )
 
@@ -39,9 +40,13 @@ const (
 )
 
 type Error struct {
-	Err   error
-	Code  int
-	Level int
+	Err        error
+	Code       int
+	Level      int
+	ErrorCode  env.Value
+	ErrorInfo  string
+	ErrorLine  int
+	ErrorStack []env.Value
 }
 
 func ErrorWithCode(msg string, code int) error {
@@ -70,6 +75,30 @@ func (e Error) Unwrap() error {
 	return e.Err
 }
 
+func (e Error) Info() string {
+	if e.ErrorInfo == "" && e.Err != nil {
+		return e.Err.Error()
+	}
+	return e.ErrorInfo
+}
+
+func (e Error) Options() env.Value {
+	var (
+		keys   = []string{"-code", "-level"}
+		values = []env.Value{env.Int(int64(e.Code)), env.Int(int64(e.Level))}
+	)
+	if e.Code != ErrorErr {
+		return env.ZipDict(keys, values)
+	}
+	code := e.ErrorCode
+	if code == nil {
+		code = env.Str("NONE")
+	}
+	keys = append(keys, "-errorcode", "-errorinfo", "-errorline", "-errorstack")
+	values = append(values, code, env.Str(e.Info()), env.Int(int64(e.ErrorLine)), env.ListFrom(e.ErrorStack...))
+	return env.ZipDict(keys, values)
+}
+
 type CommandFunc func(Interpreter, []env.Value) (env.Value, error)
 
 type Executer interface {
@@ -195,14 +224,18 @@ func (b Builtin) parseOptions(i Interpreter, args []env.Value) ([]env.Value, err
 			i.Define(b.Options[x].Name, env.True())
 			continue
 		}
+		if j+1 >= len(args) {
+			return nil, fmt.Errorf("%s: missing value for option %s", b.Name, str)
+		}
+		val := args[j+1]
 		if check := b.Options[x].Check; check != nil {
-			val, err := check(args[j+1])
+			v, err := check(val)
 			if err != nil {
 				return nil, err
 			}
-			b.Options[j].Value = val
-			i.Define(str, b.Options[j].Value)
+			val = v
 		}
+		i.Define(b.Options[x].Name, val)
 		j++
 	}
 	if err := IsValid(b.Options); err != nil {
@@ -233,11 +266,11 @@ func CheckBool(v env.Value) (env.Value, error) {
 }
 
 func CheckNumber(v env.Value) (env.Value, error) {
-	_, ok := v.(env.Number)
-	if !ok {
+	n, err := v.ToNumber()
+	if err != nil {
 		return nil, ErrType
 	}
-	return v, nil
+	return n, nil
 }
 
 func CheckString(v env.Value) (env.Value, error) {
@@ -301,14 +334,31 @@ func IsSet(list []Option, name string) (int, error) {
 	return 0, fmt.Errorf("%s: option not supported", name)
 }
 
+func executeValue(i Interpreter, v env.Value) (env.Value, error) {
+	if x, ok := i.(interface {
+		ExecuteValue(env.Value) (env.Value, error)
+	}); ok {
+		return x.ExecuteValue(v)
+	}
+	return i.Execute(strings.NewReader(v.String()))
+}
+
 func testScript(i Interpreter, v env.Value) (bool, error) {
-	v, err := i.Execute(strings.NewReader(v.String()))
+	v, err := executeValue(i, v)
 	if err != nil {
 		return false, err
 	}
 	return env.ToBool(v), nil
 }
 
+func testExpr(i Interpreter, v env.Value) (bool, error) {
+	res, err := evalExpr(i, v)
+	if err != nil {
+		return false, err
+	}
+	return types.AsBool(res)
+}
+
 func hasError(es ...error) error {
 	for i := range es {
 		if es[i] != nil {
@@ -1,8 +1,11 @@
 package stdlib
 
 import (
+	"fmt"
 	"strconv"
 	"strings"
+	"unicode"
+	"unicode/utf8"
 
 	"github.com/midbel/gotcl/env"
 	"github.com/midbel/gotcl/glob"
@@ -137,6 +140,12 @@ func MakeString() Executer {
 				},
 				Run: stringMatch,
 			},
+			Builtin{
+				Name:     "is",
+				Arity:    2,
+				Variadic: true,
+				Run:      stringIs,
+			},
 			Builtin{
 				Name:     "totitle",
 				Arity:    1,
@@ -211,6 +220,89 @@ func stringMatch(i Interpreter, args []env.Value) (env.Value, error) {
 	return env.Bool(glob.Match(str, pat)), nil
 }
 
+func stringIs(i Interpreter, args []env.Value) (env.Value, error) {
+	var (
+		class  = slices.Fst(args).String()
+		strict bool
+	)
+	args = slices.Rest(args)
+	if len(args) == 2 && slices.Fst(args).String() == "-strict" {
+		strict, args = true, slices.Rest(args)
+	}
+	if len(args) != 1 {
+		return nil, fmt.Errorf("wrong # args: should be \"string is class ?-strict? str\"")
+	}
+	is, ok := stringClasses[class]
+	if !ok {
+		return nil, fmt.Errorf("bad class %q", class)
+	}
+	str := slices.Fst(args).String()
+	if str == "" {
+		return env.Bool(!strict), nil
+	}
+	return env.Bool(is(str)), nil
+}
+
+var stringClasses = map[string]func(string) bool{
+	"integer":     isInteger,
+	"entier":      isInteger,
+	"wideinteger": isWideInteger,
+	"double":      isDouble,
+	"boolean":     isBoolean,
+	"true":        isBoolValue(true),
+	"false":       isBoolValue(false),
+	"alpha":       isClass(unicode.IsLetter),
+	"alnum":       isClass(func(r rune) bool { return unicode.IsLetter(r) || unicode.IsDigit(r) }),
+	"digit":       isClass(unicode.IsDigit),
+	"space":       isClass(unicode.IsSpace),
+	"upper":       isClass(unicode.IsUpper),
+	"lower":       isClass(unicode.IsLower),
+	"punct":       isClass(unicode.IsPunct),
+	"control":     isClass(unicode.IsControl),
+	"xdigit":      isClass(func(r rune) bool { return strings.ContainsRune("0123456789abcdefABCDEF", r) }),
+	"ascii":       isClass(func(r rune) bool { return r < utf8.RuneSelf }),
+}
+
+func isInteger(str string) bool {
+	n, err := env.ParseNumber(str)
+	if err != nil {
+		return false
+	}
+	return n.(env.Number).IsInt()
+}
+
+func isWideInteger(str string) bool {
+	n, err := env.ParseNumber(str)
+	if err != nil {
+		return false
+	}
+	_, ok := n.(env.Number).Int64()
+	return ok
+}
+
+func isDouble(str string) bool {
+	_, err := env.ParseNumber(str)
+	return err == nil
+}
+
+func isBoolean(str string) bool {
+	_, err := env.ParseBool(str)
+	return err == nil
+}
+
+func isBoolValue(want bool) func(string) bool {
+	return func(str string) bool {
+		b, err := env.ParseBool(str)
+		return err == nil && b == want
+	}
+}
+
+func isClass(accept func(rune) bool) func(string) bool {
+	return func(str string) bool {
+		return strings.IndexFunc(str, func(r rune) bool { return !accept(r) }) < 0
+	}
+}
+
 func stringMap(i Interpreter, args []env.Value) (env.Value, error) {
 	var (
 		str       = slices.Fst(args).String()
@@ -0,0 +1,124 @@
+package stdlib
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/midbel/gotcl/env"
+	"github.com/midbel/slices"
+)
+
+type TraceHandler interface {
+	Interpreter
+
+	TraceAdd(string, string, []string, env.Value) error
+	TraceRemove(string, string, []string, env.Value) error
+	TraceInfo(string, string) ([]env.Value, error)
+	TraceArray(string) error
+}
+
+type traceFunc func(TraceHandler, []env.Value) (env.Value, error)
+
+func wrapTraceFunc(do traceFunc) CommandFunc {
+	return func(i Interpreter, args []env.Value) (env.Value, error) {
+		th, ok := i.(TraceHandler)
+		if !ok {
+			return nil, fmt.Errorf("interpreter can not trace variables and commands")
+		}
+		return do(th, args)
+	}
+}
+
+var traceOps = map[string][]string{
+	"variable":  {"array", "read", "unset", "write"},
+	"command":   {"delete", "rename"},
+	"execution": {"enter", "enterstep", "leave", "leavestep"},
+}
+
+func MakeTrace() Executer {
+	e := Ensemble{
+		Name: "trace",
+		Safe: true,
+		List: []Executer{
+			Builtin{
+				Name:  "add",
+				Help:  "add a trace on a variable or a command",
+				Arity: 4,
+				Run:   wrapTraceFunc(traceAdd),
+			},
+			Builtin{
+				Name:  "remove",
+				Help:  "remove a trace from a variable or a command",
+				Arity: 4,
+				Run:   wrapTraceFunc(traceRemove),
+			},
+			Builtin{
+				Name:  "info",
+				Help:  "list the traces set on a variable or a command",
+				Arity: 2,
+				Run:   wrapTraceFunc(traceInfo),
+			},
+		},
+	}
+	return sortEnsembleCommands(e)
+}
+
+func traceAdd(th TraceHandler, args []env.Value) (env.Value, error) {
+	kind, ops, err := traceArgs(args)
+	if err != nil {
+		return nil, err
+	}
+	return env.EmptyStr(), th.TraceAdd(kind, slices.Snd(args).String(), ops, slices.Lst(args))
+}
+
+func traceRemove(th TraceHandler, args []env.Value) (env.Value, error) {
+	kind, ops, err := traceArgs(args)
+	if err != nil {
+		return nil, err
+	}
+	return env.EmptyStr(), th.TraceRemove(kind, slices.Snd(args).String(), ops, slices.Lst(args))
+}
+
+func traceInfo(th TraceHandler, args []env.Value) (env.Value, error) {
+	kind := slices.Fst(args).String()
+	if _, ok := traceOps[kind]; !ok {
+		return nil, fmt.Errorf("bad option %q: must be command, execution, or variable", kind)
+	}
+	list, err := th.TraceInfo(kind, slices.Snd(args).String())
+	if err != nil {
+		return nil, err
+	}
+	return env.ListFrom(list...), nil
+}
+
+func traceArgs(args []env.Value) (string, []string, error) {
+	kind := slices.Fst(args).String()
+	valid, ok := traceOps[kind]
+	if !ok {
+		return "", nil, fmt.Errorf("bad option %q: must be command, execution, or variable", kind)
+	}
+	ops, err := env.ToStringList(slices.At(args, 2))
+	if err != nil {
+		return "", nil, err
+	}
+	if len(ops) == 0 {
+		return "", nil, fmt.Errorf("bad operation list \"\": must be one or more of %s", strings.Join(valid, ", "))
+	}
+	for _, o := range ops {
+		ok := slices.Some(valid, func(v string) bool {
+			return v == o
+		})
+		if !ok {
+			return "", nil, fmt.Errorf("bad operation %q: must be %s", o, strings.Join(valid, ", "))
+		}
+	}
+	return kind, ops, nil
+}
+
+func traceArray(i Interpreter, name string) error {
+	th, ok := i.(TraceHandler)
+	if !ok {
+		return nil
+	}
+	return th.TraceArray(name)
+}
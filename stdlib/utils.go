@@ -72,6 +72,23 @@ func runChdir(i Interpreter, args []env.Value) (env.Value, error) {
 }
 
 func runPid(i Interpreter, args []env.Value) (env.Value, error) {
+	if v := slices.Fst(args); v != nil {
+		x, ok := i.(interface {
+			Pids(string) ([]int, error)
+		})
+		if !ok {
+			return nil, fmt.Errorf("interpreter can not handle channels")
+		}
+		pids, err := x.Pids(v.String())
+		if err != nil {
+			return nil, err
+		}
+		var list []env.Value
+		for _, p := range pids {
+			list = append(list, env.Int(int64(p)))
+		}
+		return env.ListFrom(list...), nil
+	}
 	pid := os.Getpid()
 	return env.Int(int64(pid)), nil
 }
@@ -3,12 +3,15 @@ package word
 import (
 	"bytes"
 	"io"
+	"strconv"
+	"strings"
 	"unicode/utf8"
 )
 
 type Scanner struct {
 	file       string
 	keepBlanks bool
+	subst      int
 
 	input []byte
 	curr  int
@@ -32,6 +35,7 @@ func Scan(r io.Reader) (*Scanner, error) {
 	}
 	s := Scanner{
 		keepBlanks: true,
+		subst:      SubstAll,
 		input:      bytes.ReplaceAll(b, []byte{cr, nl}, []byte{nl}),
 		pos:        Position{Line: 1},
 		prev:       Position{Line: 1},
@@ -42,44 +46,112 @@ func Scan(r io.Reader) (*Scanner, error) {
 	return &s, nil
 }
 
+func (s *Scanner) Text(from, to int) string {
+	if from < 0 || to > len(s.input) || from >= to {
+		return ""
+	}
+	return string(s.input[from:to])
+}
+
 func (s *Scanner) Tokenize() Word {
 	w := s.prepare()
-	if w.Type == EOF {
-		return w
-	}
 	if isBlank(s.char) {
 		s.skipBlank()
+		s.read()
+		w.Position = s.pos
+	}
+	if s.char == null {
+		w.Type = EOF
+		return w
 	}
 	switch {
 	case isVariable(s.char):
 		s.scanVariable(&w)
-	case isDigit(s.char):
+	case isDigit(s.char) || (s.char == dot && isDigit(s.peek())):
 		s.scanNumber(&w)
+	case isLetter(s.char):
+		s.scanIdent(&w)
 	case isOperator(s.char):
 		s.scanOperator(&w)
 	case s.char == lparen || s.char == rparen:
 		w.Type = Paren
+		w.Literal = string(s.char)
+	case s.char == comma:
+		w.Type = Comma
+	case s.char == dquote:
+		s.scanQuote(&w)
+	case s.char == lcurly:
+		s.scanBraces(&w)
+	case s.char == lsquare:
+		s.scanScript(&w)
 	default:
+		w.Type = Illegal
+		w.Literal = string(s.char)
 	}
 	return w
 }
 
+func (s *Scanner) scanIdent(w *Word) {
+	defer s.unread()
+	for isAlpha(s.char) || (s.char == colon && s.peek() == colon) {
+		if s.char == colon {
+			s.str.WriteRune(s.char)
+			s.read()
+		}
+		s.str.WriteRune(s.char)
+		s.read()
+	}
+	w.Type = Literal
+	w.Literal = s.str.String()
+	if k, ok := keywords[w.Literal]; ok {
+		w.Type = k
+	}
+}
+
+var keywords = map[string]rune{
+	"eq": StrEq,
+	"ne": StrNe,
+	"lt": StrLt,
+	"le": StrLe,
+	"gt": StrGt,
+	"ge": StrGe,
+	"in": In,
+	"ni": Ni,
+}
+
 func (s *Scanner) Split() Word {
 	w := s.prepare()
 	if w.Type == EOF {
 		return w
 	}
 	switch {
-	case isVariable(s.char):
+	case isVariable(s.char) && s.subst&SubstVariables != 0 && isVariableStart(s.peek()):
 		s.scanVariable(&w)
-	case isScript(s.char):
+	case isScript(s.char) && s.subst&SubstCommands != 0:
 		s.scanScript(&w)
 	default:
-		s.scanLiteral(&w, isQuotedDelimiter)
+		if s.isSubstDelimiter(s.char) {
+			s.str.WriteRune(s.char)
+			s.read()
+		}
+		s.scanLiteral(&w, s.isSubstDelimiter)
 	}
 	return w
 }
 
+func (s *Scanner) isSubstDelimiter(c rune) bool {
+	switch {
+	case c == null:
+		return true
+	case isVariable(c):
+		return s.subst&SubstVariables != 0
+	case isScript(c):
+		return s.subst&SubstCommands != 0
+	default:
+		return false
+	}
+}
+
 func (s *Scanner) Scan() Word {
 	w := s.prepare()
 	if w.Type == EOF {
@@ -143,6 +215,7 @@ func (s *Scanner) scanOperator(w *Word) {
 	case equal:
 		w.Type = Literal
 		if s.peek() == equal {
+			s.read()
 			w.Type = Eq
 		}
 	case bang:
@@ -198,54 +271,31 @@ func (s *Scanner) scanOperator(w *Word) {
 	}
 }
 
+// scanNumber keeps every character that can be part of a number and leaves
+// it to env.ParseNumber, used by the parser, to accept or reject the token.
 func (s *Scanner) scanNumber(w *Word) {
 	defer s.unread()
-	if s.char == '0' {
-		var accept func(rune) bool
-		switch k := s.peek(); k {
-		case 'x':
-			accept = isHexa
-		case 'b':
-			accept = isBinary
-		case 'o':
-			accept = isOctal
-		default:
-		}
-		if accept != nil {
-			s.scanInteger(w, accept)
-			return
-		}
-	}
-	for isDigit(s.char) {
+	var (
+		hexa = s.char == '0' && (s.peek() == 'x' || s.peek() == 'X')
+		prev rune
+	)
+	for {
 		s.str.WriteRune(s.char)
+		prev = s.char
 		s.read()
+		if isAlpha(s.char) || s.char == dot {
+			continue
+		}
+		if (s.char == plus || s.char == minus) && !hexa && (prev == 'e' || prev == 'E') {
+			continue
+		}
+		break
 	}
 	w.Type = Int
 	w.Literal = s.str.String()
-	if s.char != dot {
-		return
+	if !hexa && strings.ContainsAny(w.Literal, ".eE") {
+		w.Type = Float
 	}
-	s.str.WriteRune(s.char)
-	s.read()
-	for isDigit(s.char) {
-		s.str.WriteRune(s.char)
-		s.read()
-	}
-	w.Type = Float
-	w.Literal = s.str.String()
-}
-
-func (s *Scanner) scanInteger(w *Word, accept func(rune) bool) {
-	s.str.WriteRune(s.char)
-	s.read()
-	s.str.WriteRune(s.char)
-	s.read()
-	for accept(s.char) {
-		s.str.WriteRune(s.char)
-		s.read()
-	}
-	w.Type = Int
-	w.Literal = s.str.String()
 }
 
 func (s *Scanner) scanQuote(w *Word) {
@@ -287,10 +337,35 @@ func (s *Scanner) scanVariable(w *Word) {
 	if escaped && s.char == rcurly {
 		s.read()
 	}
+	if !escaped && s.char == lparen && s.str.Len() > 0 {
+		s.scanIndex()
+	}
 	w.Type = Variable
 	w.Literal = s.str.String()
 }
 
+func (s *Scanner) scanIndex() {
+	var depth int
+	for !s.done() {
+		s.str.WriteRune(s.char)
+		switch s.char {
+		case lsquare:
+			depth++
+		case rsquare:
+			depth--
+		case backslash:
+			s.read()
+			s.str.WriteRune(s.char)
+		case rparen:
+			if depth == 0 {
+				s.read()
+				return
+			}
+		}
+		s.read()
+	}
+}
+
 func (s *Scanner) scanUntil(w *Word, starts, ends rune) {
 	var scan func(bool)
 	scan = func(top bool) {
@@ -316,6 +391,11 @@ func (s *Scanner) scanUntil(w *Word, starts, ends rune) {
 func (s *Scanner) scanBraces(w *Word) {
 	w.Type = Block
 	s.scanUntil(w, lcurly, rcurly)
+	if w.Type == Block && w.Literal == "*" {
+		if c := s.peek(); c != null && !isBlank(c) && !isEOL(c) {
+			w.Type = Expand
+		}
+	}
 }
 
 func (s *Scanner) scanScript(w *Word) {
@@ -334,23 +414,63 @@ func (s *Scanner) scanLiteral(w *Word, isDone func(rune) bool) {
 }
 
 func (s *Scanner) escape() rune {
-	if s.char != backslash {
+	if s.char != backslash || s.subst&SubstBackslashes == 0 {
 		return s.char
 	}
-	switch s.peek() {
-	case 'a', 'b', 'f', 'n', 'r', 't', 'v', dollar, space, backslash, dquote:
+	switch c := s.peek(); {
+	case c == null:
+		return s.char
+	case c == 'x':
 		s.read()
-		return escapeChar(s.char)
+		return s.escapeCode(2, 16, isHexa)
+	case c == 'u':
+		s.read()
+		return s.escapeCode(4, 16, isHexa)
+	case c == 'U':
+		s.read()
+		return s.escapeCode(8, 16, isHexa)
+	case isOctal(c):
+		return s.escapeCode(3, 8, isOctal)
+	case c == nl:
+		s.read()
+		for isBlank(s.peek()) {
+			s.read()
+		}
+		return space
 	default:
-		return s.char
+		s.read()
+		return escapeChar(s.char)
+	}
+}
+
+func (s *Scanner) escapeCode(size, base int, accept func(rune) bool) rune {
+	var (
+		code rune
+		char = s.char
+	)
+	for i := 0; i < size && accept(s.peek()); i++ {
+		s.read()
+		n, _ := strconv.ParseInt(string(s.char), base, 32)
+		code = code*rune(base) + rune(n)
+		char = 0
+	}
+	if char != 0 {
+		return char
 	}
+	if base == 8 {
+		code &= 0xff
+	}
+	if !utf8.ValidRune(code) {
+		return utf8.RuneError
+	}
+	return code
 }
 
 func (s *Scanner) scanComment(w *Word) {
 	s.read()
 	s.skipBlank()
 	s.read()
-	for s.char != nl {
+	for s.char != nl && s.char != null {
 		s.str.WriteRune(s.char)
 		s.read()
 	}
@@ -380,6 +500,7 @@ func (s *Scanner) read() {
 		s.char = null
 		s.pos.Column = 1
 		s.pos.Line++
+		s.pos.Offset = len(s.input)
 		return
 	}
 	old := s.char
@@ -387,6 +508,7 @@ func (s *Scanner) read() {
 	s.curr = s.next
 	s.next += size
 	s.char = r
+	s.pos.Offset = s.curr
 
 	if old == nl {
 		s.pos.Line++
@@ -462,6 +584,7 @@ const (
 	caret      = '^'
 	question   = '?'
 	colon      = ':'
+	comma      = ','
 	lparen     = '('
 	rparen     = ')'
 	lcurly     = '{'
@@ -503,10 +626,6 @@ func isOctal(c rune) bool {
 	return c >= '0' && c <= '7'
 }
 
-func isBinary(c rune) bool {
-	return c == '0' || c == '1'
-}
-
 func isLetter(c rune) bool {
 	return isLower(c) || isUpper(c) || c == underscore
 }
@@ -519,6 +638,10 @@ func isVariable(c rune) bool {
 	return c == dollar
 }
 
+func isVariableStart(c rune) bool {
+	return isAlpha(c) || c == colon || c == lcurly
+}
+
 func isScript(c rune) bool {
 	return c == lsquare
 }
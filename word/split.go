@@ -4,11 +4,23 @@ import (
 	"strings"
 )
 
+const (
+	SubstBackslashes = 1 << iota
+	SubstVariables
+	SubstCommands
+	SubstAll = SubstBackslashes | SubstVariables | SubstCommands
+)
+
 func Split(str string) ([]Word, error) {
+	return Subst(str, SubstAll)
+}
+
+func Subst(str string, flags int) ([]Word, error) {
 	s, err := Scan(strings.NewReader(str))
 	if err != nil {
 		return nil, err
 	}
+	s.subst = flags
 	var list []Word
 	for {
 		w := s.Split()
@@ -19,3 +31,30 @@ func Split(str string) ([]Word, error) {
 	}
 	return list, nil
 }
+
+// Unescape performs the backslash substitutions of str only.
+func Unescape(str string) string {
+	if strings.IndexByte(str, '\\') < 0 {
+		return str
+	}
+	list, err := Subst(str, SubstBackslashes)
+	if err != nil {
+		return str
+	}
+	var buf strings.Builder
+	for _, w := range list {
+		buf.WriteString(w.Literal)
+	}
+	return buf.String()
+}
+
+func SplitIndex(str string) (string, string, bool) {
+	if !strings.HasSuffix(str, ")") {
+		return str, "", false
+	}
+	x := strings.IndexByte(str, '(')
+	if x <= 0 {
+		return str, "", false
+	}
+	return str[:x], str[x+1 : len(str)-1], true
+}
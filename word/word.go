@@ -15,6 +15,7 @@ const (
 	Script // [...]
 	Quote  // "..."
 	Block  // {...}
+	Expand // {*}
 	Comment
 	Illegal
 	Paren
@@ -42,11 +43,21 @@ const (
 	Bor
 	Bxor
 	Bnot
+	Comma
+	StrEq
+	StrNe
+	StrLt
+	StrLe
+	StrGt
+	StrGe
+	In
+	Ni
 )
 
 type Position struct {
 	Line   int
 	Column int
+	Offset int
 }
 
 func (p Position) String() string {
@@ -123,6 +134,24 @@ func (w Word) Debug() string {
 		return "<namespace>"
 	case Ternary:
 		return "<ternary>"
+	case Comma:
+		return "<comma>"
+	case StrEq:
+		return "<str-eq>"
+	case StrNe:
+		return "<str-ne>"
+	case StrLt:
+		return "<str-lessthan>"
+	case StrLe:
+		return "<str-lesseq>"
+	case StrGt:
+		return "<str-greatthan>"
+	case StrGe:
+		return "<str-greateq>"
+	case In:
+		return "<in>"
+	case Ni:
+		return "<ni>"
 	case Alt:
 		return "alternative"
 	case Int:
@@ -135,6 +164,8 @@ func (w Word) Debug() string {
 		return fmt.Sprintf("literal(%s)", w.Literal)
 	case Block:
 		return fmt.Sprintf("block(%s)", w.Literal)
+	case Expand:
+		return "<expand>"
 	case Quote:
 		return fmt.Sprintf("quote(%s)", w.Literal)
 	case Variable: